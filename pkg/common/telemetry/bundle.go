@@ -0,0 +1,15 @@
+package telemetry
+
+// Bundle related telemetry constants used by the bundle v1 API service.
+const (
+	// Bundle is the subsystem name for bundle-related counters.
+	Bundle = "bundle"
+
+	// FederatedBundleStaleWriteRejected is the name of the counter
+	// incremented when a federated bundle write (BatchSetFederatedBundle,
+	// BatchUpdateFederatedBundle, BatchSetFederatedBundleFromDocument or
+	// RefreshFederatedBundle) is rejected because its sequence number is
+	// behind the bundle already stored for the trust domain, or because the
+	// stored bundle changed concurrently and the compare-and-swap failed.
+	FederatedBundleStaleWriteRejected = "federated_bundle_stale_write_rejected"
+)
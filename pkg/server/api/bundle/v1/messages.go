@@ -0,0 +1,111 @@
+package bundle
+
+import (
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The request/response types below back RPCs this package added
+// (WatchFederatedBundles, GetBundleAsDocument, GetFederatedBundleAsDocument,
+// RefreshFederatedBundle, BatchSetFederatedBundleFromDocument) that have no
+// counterpart yet in github.com/spiffe/spire-api-sdk: there has been no
+// corresponding .proto change and SDK release, so bundlev1 doesn't define
+// them. They're defined here, in this package, so the service type-checks
+// and its logic is testable today. None of them are reachable over gRPC
+// yet - that requires the real proto messages to land in spire-api-sdk and
+// bundlev1.BundleServer/RegisterBundleServer to be regenerated to include
+// these methods. Once that lands, these types (and the methods that use
+// them) should be deleted in favor of the generated ones; the method
+// bodies themselves shouldn't need to change.
+
+// WatchFederatedBundlesRequest requests a stream of federated bundle change
+// events. An empty TrustDomains matches every federated trust domain.
+//
+// SinceIndex resumes a previous stream without a full re-snapshot: if it
+// matches the watch hub's index at the moment this call registers (i.e.
+// nothing changed between the old stream ending and this one starting),
+// the initial snapshot is skipped and streaming picks up with no gap. Any
+// other value - including 0, for a fresh watch - gets a full snapshot,
+// since the hub doesn't retain enough history to replay just the missed
+// changes.
+type WatchFederatedBundlesRequest struct {
+	TrustDomains []string
+	OutputMask   *types.BundleMask
+	SinceIndex   uint64
+}
+
+// WatchEvent_EventType identifies what kind of change a WatchEvent
+// describes.
+type WatchEvent_EventType int32 //nolint:revive // matches the proto-generated naming this mirrors
+
+const (
+	WatchEvent_ADDED WatchEvent_EventType = iota //nolint:revive // matches the proto-generated naming this mirrors
+	WatchEvent_UPDATED
+	WatchEvent_DELETED
+)
+
+// WatchEvent describes a single federated bundle change, as delivered by
+// WatchFederatedBundles. For WatchEvent_DELETED, Bundle only carries the
+// trust domain of the bundle that was removed.
+type WatchEvent struct {
+	Type          WatchEvent_EventType
+	Bundle        *types.Bundle
+	ModifiedIndex uint64
+}
+
+// Bundle_WatchFederatedBundlesServer is the server-side stream handle
+// WatchFederatedBundles sends events on. It has the same shape a
+// protoc-gen-go-grpc server-streaming method would generate.
+type Bundle_WatchFederatedBundlesServer interface {
+	Send(*WatchEvent) error
+	grpc.ServerStream
+}
+
+// GetBundleAsDocumentRequest requests the server's own bundle as a
+// canonical SPIFFE Trust Domain Bundle document.
+type GetBundleAsDocumentRequest struct{}
+
+// GetFederatedBundleAsDocumentRequest requests a federated bundle as a
+// canonical SPIFFE Trust Domain Bundle document.
+type GetFederatedBundleAsDocumentRequest struct {
+	TrustDomain string
+}
+
+// BundleDocument carries a bundle serialized as a SPIFFE Trust Domain
+// Bundle JSON document.
+type BundleDocument struct {
+	Document []byte
+}
+
+// RefreshFederatedBundleRequest asks the server to fetch the current bundle
+// from the named trust domain's configured bundle endpoint on demand.
+type RefreshFederatedBundleRequest struct {
+	TrustDomain string
+}
+
+// RefreshFederatedBundleResponse is the result of an on-demand refresh.
+type RefreshFederatedBundleResponse struct {
+	Bundle      *types.Bundle
+	RefreshedAt *timestamppb.Timestamp
+}
+
+// BatchSetFederatedBundleFromDocumentRequest upserts one or more federated
+// bundles supplied as SPIFFE Trust Domain Bundle documents.
+type BatchSetFederatedBundleFromDocumentRequest struct {
+	Bundles    []*FederatedBundleDocument
+	OutputMask *types.BundleMask
+	// EnforceSequenceNumber opts into the same sequence-number rollback
+	// protection BatchSetFederatedBundle's PolicyMask.EnforceSequenceNumber
+	// provides, so a client willing to speak JWKS instead of types.Bundle
+	// isn't the only way to bypass the check.
+	EnforceSequenceNumber bool
+}
+
+// FederatedBundleDocument is one entry of a
+// BatchSetFederatedBundleFromDocumentRequest: a trust domain paired with its
+// bundle document.
+type FederatedBundleDocument struct {
+	TrustDomain string
+	Document    []byte
+}
@@ -2,8 +2,10 @@ package bundle
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/andres-erbsen/clock"
 	"github.com/sirupsen/logrus"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	bundlev1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/bundle/v1"
@@ -17,6 +19,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // UpstreamPublisher defines the publisher interface.
@@ -32,28 +35,51 @@ func (fn UpstreamPublisherFunc) PublishJWTKey(ctx context.Context, jwtKey *commo
 	return fn(ctx, jwtKey)
 }
 
+// BundleEndpointFetcher performs a synchronous fetch of a federation
+// relationship's configured bundle endpoint (authenticating it per its
+// BundleEndpointProfile) and returns the resulting bundle.
+type BundleEndpointFetcher interface {
+	FetchBundle(ctx context.Context, relationship *datastore.FederationRelationship) (*common.Bundle, error)
+}
+
 // Config defines the bundle service configuration.
 type Config struct {
 	DataStore         datastore.DataStore
 	TrustDomain       spiffeid.TrustDomain
 	UpstreamPublisher UpstreamPublisher
+	WatchHub          *dscache.FederatedBundleWatchHub
+	Metrics           telemetry.Metrics
+	EndpointFetcher   BundleEndpointFetcher
+	Clock             clock.Clock
 }
 
 // Service defines the v1 bundle service properties.
 type Service struct {
 	bundlev1.UnsafeBundleServer
 
-	ds datastore.DataStore
-	td spiffeid.TrustDomain
-	up UpstreamPublisher
+	ds      datastore.DataStore
+	td      spiffeid.TrustDomain
+	up      UpstreamPublisher
+	hub     *dscache.FederatedBundleWatchHub
+	metrics telemetry.Metrics
+	fetcher BundleEndpointFetcher
+	clk     clock.Clock
 }
 
 // New creates a new bundle service.
 func New(config Config) *Service {
+	clk := config.Clock
+	if clk == nil {
+		clk = clock.New()
+	}
 	return &Service{
-		ds: config.DataStore,
-		td: config.TrustDomain,
-		up: config.UpstreamPublisher,
+		ds:      config.DataStore,
+		td:      config.TrustDomain,
+		up:      config.UpstreamPublisher,
+		hub:     config.WatchHub,
+		metrics: config.Metrics,
+		fetcher: config.EndpointFetcher,
+		clk:     clk,
 	}
 }
 
@@ -97,6 +123,32 @@ func (s *Service) GetBundle(ctx context.Context, req *bundlev1.GetBundleRequest)
 	return bundle, nil
 }
 
+// GetBundleAsDocument returns the server's own bundle as a canonical SPIFFE
+// Trust Domain Bundle JSON document, for clients that don't speak the
+// types.Bundle proto (e.g. a third party verifying our bundle endpoint by
+// hand).
+func (s *Service) GetBundleAsDocument(ctx context.Context, req *GetBundleAsDocumentRequest) (*BundleDocument, error) {
+	log := rpccontext.Logger(ctx)
+
+	dsResp, err := s.ds.FetchBundle(dscache.WithCache(ctx), &datastore.FetchBundleRequest{
+		TrustDomainId: s.td.IDString(),
+	})
+	if err != nil {
+		return nil, api.MakeErr(log, codes.Internal, "failed to fetch bundle", err)
+	}
+
+	if dsResp.Bundle == nil {
+		return nil, api.MakeErr(log, codes.NotFound, "bundle not found", nil)
+	}
+
+	doc, err := api.BundleToDocument(dsResp.Bundle)
+	if err != nil {
+		return nil, api.MakeErr(log, codes.Internal, "failed to marshal bundle document", err)
+	}
+
+	return &BundleDocument{Document: doc}, nil
+}
+
 // AppendBundle appends the given authorities to the given bundlev1.
 func (s *Service) AppendBundle(ctx context.Context, req *bundlev1.AppendBundleRequest) (*types.Bundle, error) {
 	log := rpccontext.Logger(ctx)
@@ -212,6 +264,200 @@ func (s *Service) ListFederatedBundles(ctx context.Context, req *bundlev1.ListFe
 	return resp, nil
 }
 
+// WatchFederatedBundles streams ADDED/UPDATED/DELETED events for federated
+// bundles as they change. A fresh watch (or one whose req.SinceIndex
+// doesn't match the hub's index at registration) starts with an atomic
+// snapshot of the bundles that currently match req.TrustDomains (an empty
+// list matches all federated bundles), together with the ModifiedIndex the
+// snapshot was taken at. A reconnecting client that sets SinceIndex to the
+// index it last observed skips that snapshot when it's still caught up -
+// i.e. nothing changed while it was disconnected - and starts receiving
+// events with no gap; see FederatedBundleWatchHub.Watch. The stream
+// resynchronizes with a fresh snapshot mid-stream, the same as on an
+// unmatched SinceIndex, whenever a watcher's buffered channel overflows or
+// the datastore's ModifiedIndex is ever observed to go backwards (for
+// example after the server was restored from an older backup), so the
+// client re-synchronizes instead of silently diverging or missing a
+// rollback.
+func (s *Service) WatchFederatedBundles(req *WatchFederatedBundlesRequest, stream Bundle_WatchFederatedBundlesServer) error {
+	ctx := stream.Context()
+	log := rpccontext.Logger(ctx)
+
+	if s.hub == nil {
+		return api.MakeErr(log, codes.Unimplemented, "federated bundle watching is not enabled", nil)
+	}
+
+	watcher, regIndex := s.hub.Watch(req.TrustDomains)
+	defer watcher.Close()
+
+	var lastIndex uint64
+	if req.SinceIndex != 0 && req.SinceIndex == regIndex {
+		// The client is already caught up as of registration: nothing
+		// changed while it was disconnected, so skip the snapshot and pick
+		// up streaming from here with no gap.
+		lastIndex = regIndex
+	} else {
+		var err error
+		lastIndex, err = s.sendFederatedBundleSnapshot(ctx, stream, req)
+		if err != nil {
+			return api.MakeErr(log, codes.Internal, "failed to send initial federated bundle snapshot", err)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events():
+			if !ok {
+				return api.MakeErr(log, codes.Unavailable, "federated bundle watch was closed", nil)
+			}
+
+			if watcher.Dropped() || (event.ModifiedIndex <= lastIndex && event.ModifiedIndex != 0) {
+				// Either Notify couldn't deliver one or more events to this
+				// watcher because its channel was full, or the datastore's
+				// index went backwards relative to what we already sent (a
+				// genuine rollback, not just a gap - indexes a filtered
+				// watcher receives are still a strictly increasing
+				// subsequence of the global stream). Either way,
+				// resynchronize with a fresh snapshot rather than risk
+				// missing or misordering a change.
+				var err error
+				lastIndex, err = s.sendFederatedBundleSnapshot(ctx, stream, req)
+				if err != nil {
+					return api.MakeErr(log, codes.Internal, "failed to resend federated bundle snapshot", err)
+				}
+				continue
+			}
+
+			we, err := bundleEventToWatchEvent(event, req.OutputMask)
+			if err != nil {
+				return api.MakeErr(log, codes.Internal, "failed to convert bundle event", err)
+			}
+			if err := stream.Send(we); err != nil {
+				return err
+			}
+			lastIndex = event.ModifiedIndex
+		}
+	}
+}
+
+// sendFederatedBundleSnapshot fetches the current set of federated bundles
+// matching req.TrustDomains and sends them as a single batch of ADDED
+// events, returning the index the snapshot was taken at. The list and the
+// index are taken together under the hub's lock (via Snapshot) so a write
+// landing in between can't produce a snapshot whose returned index doesn't
+// match what was actually sent.
+func (s *Service) sendFederatedBundleSnapshot(ctx context.Context, stream Bundle_WatchFederatedBundlesServer, req *WatchFederatedBundlesRequest) (uint64, error) {
+	filter := make(map[string]struct{}, len(req.TrustDomains))
+	for _, td := range req.TrustDomains {
+		filter[td] = struct{}{}
+	}
+
+	var dsResp *datastore.ListBundlesResponse
+	var index uint64
+	var listErr error
+	s.hub.Snapshot(func(snapshotIndex uint64) {
+		index = snapshotIndex
+		dsResp, listErr = s.ds.ListBundles(ctx, &datastore.ListBundlesRequest{})
+	})
+	if listErr != nil {
+		return 0, listErr
+	}
+
+	for _, dsBundle := range dsResp.Bundles {
+		if s.td.IDString() == dsBundle.TrustDomainId {
+			continue
+		}
+		if len(filter) > 0 {
+			if _, ok := filter[dsBundle.TrustDomainId]; !ok {
+				continue
+			}
+		}
+
+		we, err := bundleEventToWatchEvent(datastore.BundleEvent{
+			Type:          datastore.BundleEventAdded,
+			TrustDomainId: dsBundle.TrustDomainId,
+			Bundle:        dsBundle,
+			ModifiedIndex: index,
+		}, req.OutputMask)
+		if err != nil {
+			return 0, err
+		}
+		if err := stream.Send(we); err != nil {
+			return 0, err
+		}
+	}
+
+	return index, nil
+}
+
+// notifyBundleEvent wakes any WatchFederatedBundles streams interested in
+// the affected trust domain. It is a no-op if the watch hub was not
+// configured, so enabling the watch feature stays opt-in for callers of
+// New that don't pass a WatchHub. modifiedIndex must be the index the
+// datastore assigned the write that produced this event (e.g.
+// SetBundleResponse.ModifiedIndex), not one minted here, so the hub's
+// notion of "current index" reflects the datastore and survives a process
+// restart.
+func (s *Service) notifyBundleEvent(eventType datastore.BundleEventType, bundle *common.Bundle, modifiedIndex uint64) {
+	if s.hub == nil {
+		return
+	}
+	s.hub.Notify(datastore.BundleEvent{
+		Type:          eventType,
+		TrustDomainId: bundle.TrustDomainId,
+		Bundle:        bundle,
+		ModifiedIndex: modifiedIndex,
+	})
+}
+
+// countStaleFederatedBundleWrite records a rejected federated bundle write
+// (set, update, set from document or refresh) whose sequence number was
+// behind the one already stored, or whose compare-and-swap lost to a
+// concurrent write, so operators can alert on writers racing against a
+// stale copy of a bundle.
+func (s *Service) countStaleFederatedBundleWrite(ctx context.Context, td spiffeid.TrustDomain) {
+	if s.metrics == nil {
+		return
+	}
+	s.metrics.IncrCounterWithLabels([]string{telemetry.Bundle, telemetry.FederatedBundleStaleWriteRejected}, 1, []telemetry.Label{
+		{Name: telemetry.TrustDomainID, Value: td.String()},
+	})
+}
+
+func bundleEventToWatchEvent(event datastore.BundleEvent, mask *types.BundleMask) (*WatchEvent, error) {
+	var eventType WatchEvent_EventType
+	switch event.Type {
+	case datastore.BundleEventAdded:
+		eventType = WatchEvent_ADDED
+	case datastore.BundleEventUpdated:
+		eventType = WatchEvent_UPDATED
+	case datastore.BundleEventDeleted:
+		eventType = WatchEvent_DELETED
+	default:
+		return nil, fmt.Errorf("unhandled bundle event type %d", event.Type)
+	}
+
+	we := &WatchEvent{
+		Type:          eventType,
+		ModifiedIndex: event.ModifiedIndex,
+	}
+
+	if event.Type != datastore.BundleEventDeleted {
+		b, err := api.BundleToProto(event.Bundle)
+		if err != nil {
+			return nil, err
+		}
+		applyBundleMask(b, mask)
+		we.Bundle = b
+	} else {
+		we.Bundle = &types.Bundle{TrustDomain: event.TrustDomainId}
+	}
+
+	return we, nil
+}
+
 // GetFederatedBundle returns the bundle associated with the given trust domain.
 func (s *Service) GetFederatedBundle(ctx context.Context, req *bundlev1.GetFederatedBundleRequest) (*types.Bundle, error) {
 	log := rpccontext.Logger(ctx).WithField(telemetry.TrustDomainID, req.TrustDomain)
@@ -246,6 +492,122 @@ func (s *Service) GetFederatedBundle(ctx context.Context, req *bundlev1.GetFeder
 	return b, nil
 }
 
+// GetFederatedBundleAsDocument returns the bundle for the given trust
+// domain as a canonical SPIFFE Trust Domain Bundle JSON document.
+func (s *Service) GetFederatedBundleAsDocument(ctx context.Context, req *GetFederatedBundleAsDocumentRequest) (*BundleDocument, error) {
+	log := rpccontext.Logger(ctx).WithField(telemetry.TrustDomainID, req.TrustDomain)
+
+	td, err := spiffeid.TrustDomainFromString(req.TrustDomain)
+	if err != nil {
+		return nil, api.MakeErr(log, codes.InvalidArgument, "trust domain argument is not valid", err)
+	}
+
+	if s.td.Compare(td) == 0 {
+		return nil, api.MakeErr(log, codes.InvalidArgument, "getting a federated bundle for the server's own trust domain is not allowed", nil)
+	}
+
+	dsResp, err := s.ds.FetchBundle(ctx, &datastore.FetchBundleRequest{
+		TrustDomainId: td.IDString(),
+	})
+	if err != nil {
+		return nil, api.MakeErr(log, codes.Internal, "failed to fetch bundle", err)
+	}
+
+	if dsResp.Bundle == nil {
+		return nil, api.MakeErr(log, codes.NotFound, "bundle not found", nil)
+	}
+
+	doc, err := api.BundleToDocument(dsResp.Bundle)
+	if err != nil {
+		return nil, api.MakeErr(log, codes.Internal, "failed to marshal bundle document", err)
+	}
+
+	return &BundleDocument{Document: doc}, nil
+}
+
+// RefreshFederatedBundle synchronously fetches the current bundle from the
+// named trust domain's configured bundle endpoint, validates it (including
+// the sequence-number rollback check), persists it, and returns the result.
+// It lets an operator force a refresh on demand instead of waiting on the
+// federation relationship poller, and surfaces fetch/TLS errors directly in
+// the response so they don't have to go tail server logs.
+func (s *Service) RefreshFederatedBundle(ctx context.Context, req *RefreshFederatedBundleRequest) (*RefreshFederatedBundleResponse, error) {
+	log := rpccontext.Logger(ctx).WithField(telemetry.TrustDomainID, req.TrustDomain)
+
+	if err := rpccontext.RateLimit(ctx, 1); err != nil {
+		return nil, api.MakeErr(log, status.Code(err), "rejecting request due to bundle refresh rate limiting", err)
+	}
+
+	td, err := spiffeid.TrustDomainFromString(req.TrustDomain)
+	if err != nil {
+		return nil, api.MakeErr(log, codes.InvalidArgument, "trust domain argument is not valid", err)
+	}
+
+	if s.td.Compare(td) == 0 {
+		return nil, api.MakeErr(log, codes.InvalidArgument, "refreshing the bundle for the server's own trust domain is not allowed", nil)
+	}
+
+	if s.fetcher == nil {
+		return nil, api.MakeErr(log, codes.Unimplemented, "federated bundle refresh is not enabled", nil)
+	}
+
+	relResp, err := s.ds.FetchFederationRelationship(ctx, &datastore.FetchFederationRelationshipRequest{TrustDomain: td})
+	if err != nil {
+		return nil, api.MakeErr(log, codes.Internal, "failed to fetch federation relationship", err)
+	}
+	if relResp.FederationRelationship == nil {
+		return nil, api.MakeErr(log, codes.FailedPrecondition, "no federation relationship is configured for this trust domain", nil)
+	}
+
+	fetched, err := s.fetcher.FetchBundle(ctx, relResp.FederationRelationship)
+	if err != nil {
+		return nil, api.MakeErr(log, codes.FailedPrecondition, "failed to fetch bundle from bundle endpoint", err)
+	}
+
+	dsResp, err := s.ds.FetchBundle(ctx, &datastore.FetchBundleRequest{TrustDomainId: td.IDString()})
+	if err != nil {
+		return nil, api.MakeErr(log, codes.Internal, "failed to fetch current bundle", err)
+	}
+	var ifSequenceNumber *uint64
+	if dsResp.Bundle != nil {
+		if fetched.SequenceNumber < dsResp.Bundle.SequenceNumber {
+			s.countStaleFederatedBundleWrite(ctx, td)
+			return nil, api.MakeErr(log, codes.FailedPrecondition, "fetched bundle sequence number is older than the one on record", nil)
+		}
+		ifSequenceNumber = &dsResp.Bundle.SequenceNumber
+	}
+
+	// Write with IfSequenceNumber rather than trusting the read above: a
+	// concurrent writer (another RefreshFederatedBundle call, a
+	// BatchSetFederatedBundle, or the federation relationship poller) could
+	// otherwise land between the FetchBundle and this write and have its
+	// update silently clobbered.
+	setResp, err := s.ds.SetBundle(ctx, &datastore.SetBundleRequest{
+		Bundle:           fetched,
+		IfSequenceNumber: ifSequenceNumber,
+	})
+	switch {
+	case errors.Is(err, datastore.ErrSequenceConflict):
+		s.countStaleFederatedBundleWrite(ctx, td)
+		return nil, api.MakeErr(log, codes.FailedPrecondition, "bundle was modified concurrently; retry the refresh", err)
+	case err != nil:
+		return nil, api.MakeErr(log, codes.Internal, "failed to persist refreshed bundle", err)
+	}
+
+	protoBundle, err := api.BundleToProto(setResp.Bundle)
+	if err != nil {
+		return nil, api.MakeErr(log, codes.Internal, "failed to convert bundle", err)
+	}
+
+	s.notifyBundleEvent(datastore.BundleEventUpdated, setResp.Bundle, setResp.ModifiedIndex)
+
+	log.Debug("Federated bundle refreshed")
+	return &RefreshFederatedBundleResponse{
+		Bundle:      protoBundle,
+		RefreshedAt: timestamppb.New(s.clk.Now()),
+	}, nil
+}
+
 // BatchCreateFederatedBundle adds one or more bundles to the server.
 func (s *Service) BatchCreateFederatedBundle(ctx context.Context, req *bundlev1.BatchCreateFederatedBundleRequest) (*bundlev1.BatchCreateFederatedBundleResponse, error) {
 	var results []*bundlev1.BatchCreateFederatedBundleResponse_Result
@@ -305,6 +667,8 @@ func (s *Service) createFederatedBundle(ctx context.Context, b *types.Bundle, ou
 
 	applyBundleMask(protoBundle, outputMask)
 
+	s.notifyBundleEvent(datastore.BundleEventAdded, resp.Bundle, resp.ModifiedIndex)
+
 	log.Debug("Federated bundle created")
 	return &bundlev1.BatchCreateFederatedBundleResponse_Result{
 		Status: api.OK(),
@@ -312,7 +676,7 @@ func (s *Service) createFederatedBundle(ctx context.Context, b *types.Bundle, ou
 	}
 }
 
-func (s *Service) setFederatedBundle(ctx context.Context, b *types.Bundle, outputMask *types.BundleMask) *bundlev1.BatchSetFederatedBundleResponse_Result {
+func (s *Service) setFederatedBundle(ctx context.Context, b *types.Bundle, outputMask *types.BundleMask, enforceSequenceNumber bool) *bundlev1.BatchSetFederatedBundleResponse_Result {
 	log := rpccontext.Logger(ctx).WithField(telemetry.TrustDomainID, b.TrustDomain)
 
 	td, err := spiffeid.TrustDomainFromString(b.TrustDomain)
@@ -334,11 +698,56 @@ func (s *Service) setFederatedBundle(ctx context.Context, b *types.Bundle, outpu
 			Status: api.MakeStatus(log, codes.InvalidArgument, "failed to convert bundle", err),
 		}
 	}
+
+	// Rollback protection is opt-in, via PolicyMask.EnforceSequenceNumber:
+	// fetch the current bundle and write with IfSequenceNumber so a write
+	// built from a stale read can't silently clobber a newer one. Callers
+	// that don't set EnforceSequenceNumber keep the original unconditional
+	// upsert behavior.
+	var ifSequenceNumber *uint64
+	if enforceSequenceNumber {
+		dsResp, err := s.ds.FetchBundle(ctx, &datastore.FetchBundleRequest{TrustDomainId: td.IDString()})
+		if err != nil {
+			return &bundlev1.BatchSetFederatedBundleResponse_Result{
+				Status: api.MakeStatus(log, codes.Internal, "failed to fetch current bundle for sequence check", err),
+			}
+		}
+		if dsResp.Bundle != nil {
+			switch {
+			case b.SequenceNumber < dsResp.Bundle.SequenceNumber:
+				s.countStaleFederatedBundleWrite(ctx, td)
+				return &bundlev1.BatchSetFederatedBundleResponse_Result{
+					Status: api.MakeStatus(log, codes.FailedPrecondition, "bundle sequence number is older than the one on record", nil),
+				}
+			case b.SequenceNumber == dsResp.Bundle.SequenceNumber:
+				protoBundle, err := api.BundleToProto(dsResp.Bundle)
+				if err != nil {
+					return &bundlev1.BatchSetFederatedBundleResponse_Result{
+						Status: api.MakeStatus(log, codes.Internal, "failed to convert bundle", err),
+					}
+				}
+				applyBundleMask(protoBundle, outputMask)
+				return &bundlev1.BatchSetFederatedBundleResponse_Result{
+					Status: api.OK(),
+					Bundle: protoBundle,
+				}
+			}
+			ifSequenceNumber = &dsResp.Bundle.SequenceNumber
+		}
+	}
+
 	resp, err := s.ds.SetBundle(ctx, &datastore.SetBundleRequest{
-		Bundle: dsBundle,
+		Bundle:           dsBundle,
+		IfSequenceNumber: ifSequenceNumber,
 	})
 
-	if err != nil {
+	switch {
+	case errors.Is(err, datastore.ErrSequenceConflict):
+		s.countStaleFederatedBundleWrite(ctx, td)
+		return &bundlev1.BatchSetFederatedBundleResponse_Result{
+			Status: api.MakeStatus(log, codes.FailedPrecondition, "bundle was modified concurrently; refetch and retry", err),
+		}
+	case err != nil:
 		return &bundlev1.BatchSetFederatedBundleResponse_Result{
 			Status: api.MakeStatus(log, codes.Internal, "failed to set bundle", err),
 		}
@@ -352,6 +761,9 @@ func (s *Service) setFederatedBundle(ctx context.Context, b *types.Bundle, outpu
 	}
 
 	applyBundleMask(protoBundle, outputMask)
+
+	s.notifyBundleEvent(datastore.BundleEventUpdated, resp.Bundle, resp.ModifiedIndex)
+
 	log.Info("Bundle set successfully")
 	return &bundlev1.BatchSetFederatedBundleResponse_Result{
 		Status: api.OK(),
@@ -363,7 +775,7 @@ func (s *Service) setFederatedBundle(ctx context.Context, b *types.Bundle, outpu
 func (s *Service) BatchUpdateFederatedBundle(ctx context.Context, req *bundlev1.BatchUpdateFederatedBundleRequest) (*bundlev1.BatchUpdateFederatedBundleResponse, error) {
 	var results []*bundlev1.BatchUpdateFederatedBundleResponse_Result
 	for _, b := range req.Bundle {
-		results = append(results, s.updateFederatedBundle(ctx, b, req.InputMask, req.OutputMask))
+		results = append(results, s.updateFederatedBundle(ctx, b, req.InputMask, req.OutputMask, req.PolicyMask.GetEnforceSequenceNumber()))
 	}
 
 	return &bundlev1.BatchUpdateFederatedBundleResponse{
@@ -371,7 +783,7 @@ func (s *Service) BatchUpdateFederatedBundle(ctx context.Context, req *bundlev1.
 	}, nil
 }
 
-func (s *Service) updateFederatedBundle(ctx context.Context, b *types.Bundle, inputMask, outputMask *types.BundleMask) *bundlev1.BatchUpdateFederatedBundleResponse_Result {
+func (s *Service) updateFederatedBundle(ctx context.Context, b *types.Bundle, inputMask, outputMask *types.BundleMask, enforceSequenceNumber bool) *bundlev1.BatchUpdateFederatedBundleResponse_Result {
 	log := rpccontext.Logger(ctx).WithField(telemetry.TrustDomainID, b.TrustDomain)
 
 	td, err := spiffeid.TrustDomainFromString(b.TrustDomain)
@@ -393,18 +805,63 @@ func (s *Service) updateFederatedBundle(ctx context.Context, b *types.Bundle, in
 			Status: api.MakeStatus(log, codes.InvalidArgument, "failed to convert bundle", err),
 		}
 	}
+
+	// Rollback protection is opt-in, via PolicyMask.EnforceSequenceNumber:
+	// fetch the current bundle and write with IfSequenceNumber so a write
+	// built from a stale read can't silently clobber a newer one. Callers
+	// that don't set EnforceSequenceNumber keep the original behavior,
+	// including relying on UpdateBundle's own NotFound error below.
+	var ifSequenceNumber *uint64
+	if enforceSequenceNumber {
+		dsResp, err := s.ds.FetchBundle(ctx, &datastore.FetchBundleRequest{TrustDomainId: td.IDString()})
+		if err != nil {
+			return &bundlev1.BatchUpdateFederatedBundleResponse_Result{
+				Status: api.MakeStatus(log, codes.Internal, "failed to fetch current bundle for sequence check", err),
+			}
+		}
+		switch {
+		case dsResp.Bundle == nil:
+			return &bundlev1.BatchUpdateFederatedBundleResponse_Result{
+				Status: api.MakeStatus(log, codes.NotFound, "bundle not found", nil),
+			}
+		case b.SequenceNumber < dsResp.Bundle.SequenceNumber:
+			s.countStaleFederatedBundleWrite(ctx, td)
+			return &bundlev1.BatchUpdateFederatedBundleResponse_Result{
+				Status: api.MakeStatus(log, codes.FailedPrecondition, "bundle sequence number is older than the one on record", nil),
+			}
+		case b.SequenceNumber == dsResp.Bundle.SequenceNumber:
+			protoBundle, err := api.BundleToProto(dsResp.Bundle)
+			if err != nil {
+				return &bundlev1.BatchUpdateFederatedBundleResponse_Result{
+					Status: api.MakeStatus(log, codes.Internal, "failed to convert bundle", err),
+				}
+			}
+			applyBundleMask(protoBundle, outputMask)
+			return &bundlev1.BatchUpdateFederatedBundleResponse_Result{
+				Status: api.OK(),
+				Bundle: protoBundle,
+			}
+		}
+		ifSequenceNumber = &dsResp.Bundle.SequenceNumber
+	}
+
 	resp, err := s.ds.UpdateBundle(ctx, &datastore.UpdateBundleRequest{
-		Bundle:    dsBundle,
-		InputMask: api.ProtoToBundleMask(inputMask),
+		Bundle:           dsBundle,
+		InputMask:        api.ProtoToBundleMask(inputMask),
+		IfSequenceNumber: ifSequenceNumber,
 	})
 
-	switch status.Code(err) {
-	case codes.OK:
-	case codes.NotFound:
+	switch {
+	case errors.Is(err, datastore.ErrSequenceConflict):
+		s.countStaleFederatedBundleWrite(ctx, td)
+		return &bundlev1.BatchUpdateFederatedBundleResponse_Result{
+			Status: api.MakeStatus(log, codes.FailedPrecondition, "bundle was modified concurrently; refetch and retry", err),
+		}
+	case status.Code(err) == codes.NotFound:
 		return &bundlev1.BatchUpdateFederatedBundleResponse_Result{
 			Status: api.MakeStatus(log, codes.NotFound, "bundle not found", err),
 		}
-	default:
+	case err != nil:
 		return &bundlev1.BatchUpdateFederatedBundleResponse_Result{
 			Status: api.MakeStatus(log, codes.Internal, "failed to update bundle", err),
 		}
@@ -419,6 +876,8 @@ func (s *Service) updateFederatedBundle(ctx context.Context, b *types.Bundle, in
 
 	applyBundleMask(protoBundle, outputMask)
 
+	s.notifyBundleEvent(datastore.BundleEventUpdated, resp.Bundle, resp.ModifiedIndex)
+
 	log.Debug("Federated bundle updated")
 	return &bundlev1.BatchUpdateFederatedBundleResponse_Result{
 		Status: api.OK(),
@@ -430,7 +889,7 @@ func (s *Service) updateFederatedBundle(ctx context.Context, b *types.Bundle, in
 func (s *Service) BatchSetFederatedBundle(ctx context.Context, req *bundlev1.BatchSetFederatedBundleRequest) (*bundlev1.BatchSetFederatedBundleResponse, error) {
 	var results []*bundlev1.BatchSetFederatedBundleResponse_Result
 	for _, b := range req.Bundle {
-		results = append(results, s.setFederatedBundle(ctx, b, req.OutputMask))
+		results = append(results, s.setFederatedBundle(ctx, b, req.OutputMask, req.PolicyMask.GetEnforceSequenceNumber()))
 	}
 
 	return &bundlev1.BatchSetFederatedBundleResponse{
@@ -438,6 +897,114 @@ func (s *Service) BatchSetFederatedBundle(ctx context.Context, req *bundlev1.Bat
 	}, nil
 }
 
+// BatchSetFederatedBundleFromDocument upserts one or more bundles in the
+// server, each supplied as a canonical SPIFFE Trust Domain Bundle JSON
+// document rather than a types.Bundle, so clients that only speak JWKS
+// don't have to translate.
+func (s *Service) BatchSetFederatedBundleFromDocument(ctx context.Context, req *BatchSetFederatedBundleFromDocumentRequest) (*bundlev1.BatchSetFederatedBundleResponse, error) {
+	var results []*bundlev1.BatchSetFederatedBundleResponse_Result
+	for _, doc := range req.Bundles {
+		results = append(results, s.setFederatedBundleFromDocument(ctx, doc, req.OutputMask, req.EnforceSequenceNumber))
+	}
+
+	return &bundlev1.BatchSetFederatedBundleResponse{
+		Results: results,
+	}, nil
+}
+
+func (s *Service) setFederatedBundleFromDocument(ctx context.Context, doc *FederatedBundleDocument, outputMask *types.BundleMask, enforceSequenceNumber bool) *bundlev1.BatchSetFederatedBundleResponse_Result {
+	log := rpccontext.Logger(ctx).WithField(telemetry.TrustDomainID, doc.TrustDomain)
+
+	td, err := spiffeid.TrustDomainFromString(doc.TrustDomain)
+	if err != nil {
+		return &bundlev1.BatchSetFederatedBundleResponse_Result{
+			Status: api.MakeStatus(log, codes.InvalidArgument, "trust domain argument is not valid", err),
+		}
+	}
+
+	if s.td.Compare(td) == 0 {
+		return &bundlev1.BatchSetFederatedBundleResponse_Result{
+			Status: api.MakeStatus(log, codes.InvalidArgument, "setting a federated bundle for the server's own trust domain is not allowed", nil),
+		}
+	}
+
+	dsBundle, err := api.DocumentToBundle(td.IDString(), doc.Document)
+	if err != nil {
+		return &bundlev1.BatchSetFederatedBundleResponse_Result{
+			Status: api.MakeStatus(log, codes.InvalidArgument, "failed to parse bundle document", err),
+		}
+	}
+
+	// A document-sourced bundle is subject to the same opt-in rollback
+	// protection as setFederatedBundle: EnforceSequenceNumber lets a client
+	// willing to speak JWKS instead of types.Bundle ask for the same
+	// sequence-number check, but it isn't forced on every caller.
+	var ifSequenceNumber *uint64
+	if enforceSequenceNumber {
+		dsResp, err := s.ds.FetchBundle(ctx, &datastore.FetchBundleRequest{TrustDomainId: td.IDString()})
+		if err != nil {
+			return &bundlev1.BatchSetFederatedBundleResponse_Result{
+				Status: api.MakeStatus(log, codes.Internal, "failed to fetch current bundle for sequence check", err),
+			}
+		}
+		if dsResp.Bundle != nil {
+			switch {
+			case dsBundle.SequenceNumber < dsResp.Bundle.SequenceNumber:
+				s.countStaleFederatedBundleWrite(ctx, td)
+				return &bundlev1.BatchSetFederatedBundleResponse_Result{
+					Status: api.MakeStatus(log, codes.FailedPrecondition, "bundle sequence number is older than the one on record", nil),
+				}
+			case dsBundle.SequenceNumber == dsResp.Bundle.SequenceNumber:
+				protoBundle, err := api.BundleToProto(dsResp.Bundle)
+				if err != nil {
+					return &bundlev1.BatchSetFederatedBundleResponse_Result{
+						Status: api.MakeStatus(log, codes.Internal, "failed to convert bundle", err),
+					}
+				}
+				applyBundleMask(protoBundle, outputMask)
+				return &bundlev1.BatchSetFederatedBundleResponse_Result{
+					Status: api.OK(),
+					Bundle: protoBundle,
+				}
+			}
+			ifSequenceNumber = &dsResp.Bundle.SequenceNumber
+		}
+	}
+
+	resp, err := s.ds.SetBundle(ctx, &datastore.SetBundleRequest{
+		Bundle:           dsBundle,
+		IfSequenceNumber: ifSequenceNumber,
+	})
+	switch {
+	case errors.Is(err, datastore.ErrSequenceConflict):
+		s.countStaleFederatedBundleWrite(ctx, td)
+		return &bundlev1.BatchSetFederatedBundleResponse_Result{
+			Status: api.MakeStatus(log, codes.FailedPrecondition, "bundle was modified concurrently; refetch and retry", err),
+		}
+	case err != nil:
+		return &bundlev1.BatchSetFederatedBundleResponse_Result{
+			Status: api.MakeStatus(log, codes.Internal, "failed to set bundle", err),
+		}
+	}
+
+	protoBundle, err := api.BundleToProto(resp.Bundle)
+	if err != nil {
+		return &bundlev1.BatchSetFederatedBundleResponse_Result{
+			Status: api.MakeStatus(log, codes.Internal, "failed to convert bundle", err),
+		}
+	}
+
+	applyBundleMask(protoBundle, outputMask)
+
+	s.notifyBundleEvent(datastore.BundleEventUpdated, resp.Bundle, resp.ModifiedIndex)
+
+	log.Debug("Federated bundle set from document")
+	return &bundlev1.BatchSetFederatedBundleResponse_Result{
+		Status: api.OK(),
+		Bundle: protoBundle,
+	}
+}
+
 // BatchDeleteFederatedBundle removes one or more bundles from the server.
 func (s *Service) BatchDeleteFederatedBundle(ctx context.Context, req *bundlev1.BatchDeleteFederatedBundleRequest) (*bundlev1.BatchDeleteFederatedBundleResponse, error) {
 	log := rpccontext.Logger(ctx)
@@ -475,7 +1042,7 @@ func (s *Service) deleteFederatedBundle(ctx context.Context, log logrus.FieldLog
 		}
 	}
 
-	_, err = s.ds.DeleteBundle(ctx, &datastore.DeleteBundleRequest{
+	deleteResp, err := s.ds.DeleteBundle(ctx, &datastore.DeleteBundleRequest{
 		TrustDomainId: td.IDString(),
 		Mode:          mode,
 	})
@@ -483,6 +1050,7 @@ func (s *Service) deleteFederatedBundle(ctx context.Context, log logrus.FieldLog
 	code := status.Code(err)
 	switch code {
 	case codes.OK:
+		s.notifyBundleEvent(datastore.BundleEventDeleted, &common.Bundle{TrustDomainId: td.IDString()}, deleteResp.ModifiedIndex)
 		return &bundlev1.BatchDeleteFederatedBundleResponse_Result{
 			Status:      api.OK(),
 			TrustDomain: trustDomain,
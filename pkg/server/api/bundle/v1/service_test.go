@@ -0,0 +1,219 @@
+package bundle
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/spiffe/spire/pkg/server/plugin/datastore"
+	"github.com/spiffe/spire/proto/spire/common"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testServerTrustDomain = "spiffe://example.org"
+	testFederatedTD       = "spiffe://federated.test"
+)
+
+func TestSetFederatedBundleEnforceSequenceNumberRejectsOlderWrite(t *testing.T) {
+	ds := newFakeDataStore()
+	ds.bundles[testFederatedTD] = &common.Bundle{TrustDomainId: testFederatedTD, SequenceNumber: 5}
+
+	service := newTestService(t, ds)
+
+	result := service.setFederatedBundle(context.Background(), &types.Bundle{
+		TrustDomain:    testFederatedTD,
+		SequenceNumber: 3,
+	}, nil, true)
+
+	require.Equal(t, int32(codes.FailedPrecondition), result.Status.Code)
+	require.Equal(t, uint64(5), ds.bundles[testFederatedTD].SequenceNumber, "stale write must not be applied")
+}
+
+func TestSetFederatedBundleEnforceSequenceNumberAllowsNewerWrite(t *testing.T) {
+	ds := newFakeDataStore()
+	ds.bundles[testFederatedTD] = &common.Bundle{TrustDomainId: testFederatedTD, SequenceNumber: 5}
+
+	service := newTestService(t, ds)
+
+	result := service.setFederatedBundle(context.Background(), &types.Bundle{
+		TrustDomain:    testFederatedTD,
+		SequenceNumber: 6,
+	}, nil, true)
+
+	require.Equal(t, int32(codes.OK), result.Status.Code)
+	require.Equal(t, uint64(6), ds.bundles[testFederatedTD].SequenceNumber)
+}
+
+func TestSetFederatedBundleWithoutEnforcementAllowsOlderWrite(t *testing.T) {
+	ds := newFakeDataStore()
+	ds.bundles[testFederatedTD] = &common.Bundle{TrustDomainId: testFederatedTD, SequenceNumber: 5}
+
+	service := newTestService(t, ds)
+
+	result := service.setFederatedBundle(context.Background(), &types.Bundle{
+		TrustDomain:    testFederatedTD,
+		SequenceNumber: 1,
+	}, nil, false)
+
+	require.Equal(t, int32(codes.OK), result.Status.Code)
+	require.Equal(t, uint64(1), ds.bundles[testFederatedTD].SequenceNumber, "unenforced write should overwrite unconditionally")
+}
+
+func TestUpdateFederatedBundleEnforceSequenceNumberRejectsOlderWrite(t *testing.T) {
+	ds := newFakeDataStore()
+	ds.bundles[testFederatedTD] = &common.Bundle{TrustDomainId: testFederatedTD, SequenceNumber: 5}
+
+	service := newTestService(t, ds)
+
+	result := service.updateFederatedBundle(context.Background(), &types.Bundle{
+		TrustDomain:    testFederatedTD,
+		SequenceNumber: 4,
+	}, nil, nil, true)
+
+	require.Equal(t, int32(codes.FailedPrecondition), result.Status.Code)
+	require.Equal(t, uint64(5), ds.bundles[testFederatedTD].SequenceNumber)
+}
+
+func TestUpdateFederatedBundleEnforceSequenceNumberNotFound(t *testing.T) {
+	ds := newFakeDataStore()
+	service := newTestService(t, ds)
+
+	result := service.updateFederatedBundle(context.Background(), &types.Bundle{
+		TrustDomain:    testFederatedTD,
+		SequenceNumber: 1,
+	}, nil, nil, true)
+
+	require.Equal(t, int32(codes.NotFound), result.Status.Code)
+}
+
+func newTestService(t *testing.T, ds datastore.DataStore) *Service {
+	t.Helper()
+	td, err := spiffeid.TrustDomainFromString(testServerTrustDomain)
+	require.NoError(t, err)
+	return New(Config{
+		DataStore:   ds,
+		TrustDomain: td,
+	})
+}
+
+// fakeDataStore is a minimal in-memory datastore.DataStore used to exercise
+// the sequence-number compare-and-swap paths without a real plugin backend.
+type fakeDataStore struct {
+	mu      sync.Mutex
+	bundles map[string]*common.Bundle
+	index   uint64
+}
+
+func newFakeDataStore() *fakeDataStore {
+	return &fakeDataStore{bundles: make(map[string]*common.Bundle)}
+}
+
+func (f *fakeDataStore) CountBundles(ctx context.Context) (int32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return int32(len(f.bundles)), nil
+}
+
+func (f *fakeDataStore) FetchBundle(ctx context.Context, req *datastore.FetchBundleRequest) (*datastore.FetchBundleResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &datastore.FetchBundleResponse{Bundle: f.bundles[req.TrustDomainId]}, nil
+}
+
+func (f *fakeDataStore) ListBundles(ctx context.Context, req *datastore.ListBundlesRequest) (*datastore.ListBundlesResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &datastore.ListBundlesResponse{}
+	for _, b := range f.bundles {
+		resp.Bundles = append(resp.Bundles, b)
+	}
+	return resp, nil
+}
+
+func (f *fakeDataStore) CreateBundle(ctx context.Context, req *datastore.CreateBundleRequest) (*datastore.CreateBundleResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.bundles[req.Bundle.TrustDomainId]; ok {
+		return nil, status.Error(codes.AlreadyExists, "bundle already exists")
+	}
+	f.index++
+	f.bundles[req.Bundle.TrustDomainId] = req.Bundle
+	return &datastore.CreateBundleResponse{Bundle: req.Bundle, ModifiedIndex: f.index}, nil
+}
+
+func (f *fakeDataStore) AppendBundle(ctx context.Context, req *datastore.AppendBundleRequest) (*datastore.AppendBundleResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.index++
+	f.bundles[req.Bundle.TrustDomainId] = req.Bundle
+	return &datastore.AppendBundleResponse{Bundle: req.Bundle, ModifiedIndex: f.index}, nil
+}
+
+func (f *fakeDataStore) SetBundle(ctx context.Context, req *datastore.SetBundleRequest) (*datastore.SetBundleResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing := f.bundles[req.Bundle.TrustDomainId]
+	if req.IfSequenceNumber != nil {
+		if existing == nil || existing.SequenceNumber != *req.IfSequenceNumber {
+			return nil, datastore.ErrSequenceConflict
+		}
+	}
+	f.index++
+	f.bundles[req.Bundle.TrustDomainId] = req.Bundle
+	return &datastore.SetBundleResponse{Bundle: req.Bundle, ModifiedIndex: f.index}, nil
+}
+
+func (f *fakeDataStore) UpdateBundle(ctx context.Context, req *datastore.UpdateBundleRequest) (*datastore.UpdateBundleResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing := f.bundles[req.Bundle.TrustDomainId]
+	if existing == nil {
+		return nil, status.Error(codes.NotFound, "bundle not found")
+	}
+	if req.IfSequenceNumber != nil && existing.SequenceNumber != *req.IfSequenceNumber {
+		return nil, datastore.ErrSequenceConflict
+	}
+	f.index++
+	f.bundles[req.Bundle.TrustDomainId] = req.Bundle
+	return &datastore.UpdateBundleResponse{Bundle: req.Bundle, ModifiedIndex: f.index}, nil
+}
+
+func (f *fakeDataStore) DeleteBundle(ctx context.Context, req *datastore.DeleteBundleRequest) (*datastore.DeleteBundleResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.bundles[req.TrustDomainId]; !ok {
+		return nil, status.Error(codes.NotFound, "bundle not found")
+	}
+	f.index++
+	delete(f.bundles, req.TrustDomainId)
+	return &datastore.DeleteBundleResponse{ModifiedIndex: f.index}, nil
+}
+
+func (f *fakeDataStore) FetchFederationRelationship(ctx context.Context, req *datastore.FetchFederationRelationshipRequest) (*datastore.FetchFederationRelationshipResponse, error) {
+	return &datastore.FetchFederationRelationshipResponse{}, nil
+}
+
+func (f *fakeDataStore) ListFederationRelationships(ctx context.Context, req *datastore.ListFederationRelationshipsRequest) (*datastore.ListFederationRelationshipsResponse, error) {
+	return &datastore.ListFederationRelationshipsResponse{}, nil
+}
+
+func (f *fakeDataStore) CreateFederationRelationship(ctx context.Context, req *datastore.CreateFederationRelationshipRequest) (*datastore.CreateFederationRelationshipResponse, error) {
+	return &datastore.CreateFederationRelationshipResponse{}, nil
+}
+
+func (f *fakeDataStore) UpdateFederationRelationship(ctx context.Context, req *datastore.UpdateFederationRelationshipRequest) (*datastore.UpdateFederationRelationshipResponse, error) {
+	return &datastore.UpdateFederationRelationshipResponse{}, nil
+}
+
+func (f *fakeDataStore) SetFederationRelationship(ctx context.Context, req *datastore.SetFederationRelationshipRequest) (*datastore.SetFederationRelationshipResponse, error) {
+	return &datastore.SetFederationRelationshipResponse{}, nil
+}
+
+func (f *fakeDataStore) DeleteFederationRelationship(ctx context.Context, req *datastore.DeleteFederationRelationshipRequest) error {
+	return nil
+}
@@ -0,0 +1,141 @@
+package bundle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire/pkg/server/cache/dscache"
+	"github.com/spiffe/spire/pkg/server/plugin/datastore"
+	"github.com/spiffe/spire/proto/spire/common"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestWatchFederatedBundlesSendsSnapshotThenEvents(t *testing.T) {
+	ds := newFakeDataStore()
+	ds.bundles[testFederatedTD] = &common.Bundle{TrustDomainId: testFederatedTD, SequenceNumber: 1}
+
+	hub := dscache.NewFederatedBundleWatchHub()
+	service := newTestServiceWithHub(t, ds, hub)
+
+	stream := newFakeWatchStream()
+	done := make(chan error, 1)
+	go func() {
+		done <- service.WatchFederatedBundles(&WatchFederatedBundlesRequest{}, stream)
+	}()
+
+	snapshot := stream.requireNext(t)
+	require.Equal(t, WatchEvent_ADDED, snapshot.Type)
+	require.Equal(t, testFederatedTD, snapshot.Bundle.TrustDomain)
+
+	updated := &common.Bundle{TrustDomainId: testFederatedTD, SequenceNumber: 2}
+	ds.bundles[testFederatedTD] = updated
+	hub.Notify(datastore.BundleEvent{
+		Type:          datastore.BundleEventUpdated,
+		TrustDomainId: testFederatedTD,
+		Bundle:        updated,
+		ModifiedIndex: hub.Index() + 1,
+	})
+
+	event := stream.requireNext(t)
+	require.Equal(t, WatchEvent_UPDATED, event.Type)
+	require.Equal(t, uint64(2), event.Bundle.SequenceNumber)
+
+	stream.cancel()
+	require.NoError(t, <-done)
+}
+
+func TestWatchFederatedBundlesResyncsOnBackwardIndex(t *testing.T) {
+	ds := newFakeDataStore()
+	ds.bundles[testFederatedTD] = &common.Bundle{TrustDomainId: testFederatedTD, SequenceNumber: 1}
+
+	hub := dscache.NewFederatedBundleWatchHub()
+	service := newTestServiceWithHub(t, ds, hub)
+
+	// Advance the hub past index 0 before watching: an event carrying
+	// ModifiedIndex 0 is never treated as a rollback.
+	hub.Notify(datastore.BundleEvent{
+		Type:          datastore.BundleEventUpdated,
+		TrustDomainId: testFederatedTD,
+		Bundle:        ds.bundles[testFederatedTD],
+		ModifiedIndex: 1,
+	})
+
+	stream := newFakeWatchStream()
+	done := make(chan error, 1)
+	go func() {
+		done <- service.WatchFederatedBundles(&WatchFederatedBundlesRequest{}, stream)
+	}()
+
+	stream.requireNext(t) // initial snapshot
+
+	// An event whose ModifiedIndex doesn't advance past the snapshot index
+	// looks like a rollback and should trigger a resend of the snapshot
+	// rather than being delivered as a regular event.
+	hub.Notify(datastore.BundleEvent{
+		Type:          datastore.BundleEventUpdated,
+		TrustDomainId: testFederatedTD,
+		Bundle:        ds.bundles[testFederatedTD],
+		ModifiedIndex: hub.Index(),
+	})
+
+	resync := stream.requireNext(t)
+	require.Equal(t, WatchEvent_ADDED, resync.Type, "a rolled-back index should be resynced with a fresh snapshot, not delivered as an event")
+
+	stream.cancel()
+	require.NoError(t, <-done)
+}
+
+func newTestServiceWithHub(t *testing.T, ds *fakeDataStore, hub *dscache.FederatedBundleWatchHub) *Service {
+	t.Helper()
+	td, err := spiffeid.TrustDomainFromString(testServerTrustDomain)
+	require.NoError(t, err)
+	return New(Config{
+		DataStore:   ds,
+		TrustDomain: td,
+		WatchHub:    hub,
+	})
+}
+
+// fakeWatchStream is a minimal Bundle_WatchFederatedBundlesServer for testing
+// WatchFederatedBundles without a real gRPC connection.
+type fakeWatchStream struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	events chan *WatchEvent
+}
+
+func newFakeWatchStream() *fakeWatchStream {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &fakeWatchStream{
+		ctx:    ctx,
+		cancel: cancel,
+		events: make(chan *WatchEvent, 16),
+	}
+}
+
+func (f *fakeWatchStream) Send(event *WatchEvent) error {
+	f.events <- event
+	return nil
+}
+
+func (f *fakeWatchStream) requireNext(t *testing.T) *WatchEvent {
+	t.Helper()
+	select {
+	case event := <-f.events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for watch event")
+		return nil
+	}
+}
+
+func (f *fakeWatchStream) Context() context.Context { return f.ctx }
+
+func (f *fakeWatchStream) SetHeader(metadata.MD) error  { return nil }
+func (f *fakeWatchStream) SendHeader(metadata.MD) error { return nil }
+func (f *fakeWatchStream) SetTrailer(metadata.MD)       {}
+func (f *fakeWatchStream) SendMsg(m interface{}) error  { return nil }
+func (f *fakeWatchStream) RecvMsg(m interface{}) error  { return nil }
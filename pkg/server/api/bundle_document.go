@@ -0,0 +1,208 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/spiffe/spire/proto/spire/common"
+)
+
+// bundleDocument is the canonical SPIFFE Trust Domain Bundle format: a JWKS
+// extended with spiffe_sequence and spiffe_refresh_hint, as described by the
+// SPIFFE Trust Domain and Bundle specification. It is the wire format
+// clients speak when they don't understand the types.Bundle proto.
+type bundleDocument struct {
+	Keys              []bundleDocumentKey `json:"keys"`
+	SPIFFESequence    uint64              `json:"spiffe_sequence,omitempty"`
+	SPIFFERefreshHint int64               `json:"spiffe_refresh_hint,omitempty"`
+}
+
+type bundleDocumentKey struct {
+	Use string   `json:"use"`
+	Kty string   `json:"kty,omitempty"`
+	Kid string   `json:"kid,omitempty"`
+	Crv string   `json:"crv,omitempty"`
+	X   string   `json:"x,omitempty"`
+	Y   string   `json:"y,omitempty"`
+	N   string   `json:"n,omitempty"`
+	E   string   `json:"e,omitempty"`
+	X5c []string `json:"x5c,omitempty"`
+}
+
+const (
+	keyUseX509SVID = "x509-svid"
+	keyUseJWTSVID  = "jwt-svid"
+)
+
+// BundleToDocument serializes the given bundle into the canonical SPIFFE
+// Trust Domain Bundle JSON document (a JWKS carrying x509-svid and
+// jwt-svid keys).
+func BundleToDocument(bundle *common.Bundle) ([]byte, error) {
+	if bundle == nil {
+		return nil, fmt.Errorf("missing bundle")
+	}
+
+	doc := bundleDocument{
+		SPIFFERefreshHint: bundle.RefreshHint,
+		SPIFFESequence:    bundle.SequenceNumber,
+	}
+
+	for _, rootCA := range bundle.RootCas {
+		doc.Keys = append(doc.Keys, bundleDocumentKey{
+			Use: keyUseX509SVID,
+			X5c: []string{base64.StdEncoding.EncodeToString(rootCA.DerBytes)},
+		})
+	}
+
+	for _, jwtKey := range bundle.JwtSigningKeys {
+		pub, err := x509.ParsePKIXPublicKey(jwtKey.PkixBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JWT authority %q: %w", jwtKey.Kid, err)
+		}
+
+		key := bundleDocumentKey{
+			Use: keyUseJWTSVID,
+			Kid: jwtKey.Kid,
+		}
+		switch pub := pub.(type) {
+		case *rsa.PublicKey:
+			key.Kty = "RSA"
+			key.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			key.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case *ecdsa.PublicKey:
+			key.Kty = "EC"
+			key.Crv = pub.Curve.Params().Name
+			size := (pub.Curve.Params().BitSize + 7) / 8
+			key.X = base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size)))
+			key.Y = base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size)))
+		default:
+			return nil, fmt.Errorf("failed to determine key type for JWT authority %q: unsupported public key type %T", jwtKey.Kid, pub)
+		}
+
+		doc.Keys = append(doc.Keys, key)
+	}
+
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bundle document: %w", err)
+	}
+	return out, nil
+}
+
+// DocumentToBundle parses a SPIFFE Trust Domain Bundle JSON document into a
+// common.Bundle for the given trust domain, validating that every key has a
+// known "use" and that its x5c entries parse as CA certificates.
+func DocumentToBundle(trustDomainID string, raw []byte) (*common.Bundle, error) {
+	var doc bundleDocument
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle document: %w", err)
+	}
+
+	bundle := &common.Bundle{
+		TrustDomainId:  trustDomainID,
+		RefreshHint:    doc.SPIFFERefreshHint,
+		SequenceNumber: doc.SPIFFESequence,
+	}
+
+	for i, key := range doc.Keys {
+		switch key.Use {
+		case keyUseX509SVID:
+			if len(key.X5c) == 0 {
+				return nil, fmt.Errorf("key %d: x509-svid key is missing x5c", i)
+			}
+			for _, entry := range key.X5c {
+				der, err := base64.StdEncoding.DecodeString(entry)
+				if err != nil {
+					return nil, fmt.Errorf("key %d: failed to decode x5c entry: %w", i, err)
+				}
+				cert, err := x509.ParseCertificate(der)
+				if err != nil {
+					return nil, fmt.Errorf("key %d: x5c entry does not parse as a certificate: %w", i, err)
+				}
+				if !cert.IsCA {
+					return nil, fmt.Errorf("key %d: x5c entry is not a CA certificate", i)
+				}
+				bundle.RootCas = append(bundle.RootCas, &common.Certificate{DerBytes: der})
+			}
+		case keyUseJWTSVID:
+			if key.Kid == "" {
+				return nil, fmt.Errorf("key %d: jwt-svid key is missing kid", i)
+			}
+			pkix, err := jwkToPKIX(key)
+			if err != nil {
+				return nil, fmt.Errorf("key %d: %w", i, err)
+			}
+			bundle.JwtSigningKeys = append(bundle.JwtSigningKeys, &common.PublicKey{
+				Kid:       key.Kid,
+				PkixBytes: pkix,
+			})
+		default:
+			return nil, fmt.Errorf("key %d: unsupported key use %q", i, key.Use)
+		}
+	}
+
+	return bundle, nil
+}
+
+// ellipticCurves maps the JWK "crv" values this package emits back to their
+// elliptic.Curve, the reverse of elliptic.Curve.Params().Name.
+var ellipticCurves = map[string]elliptic.Curve{
+	elliptic.P256().Params().Name: elliptic.P256(),
+	elliptic.P384().Params().Name: elliptic.P384(),
+	elliptic.P521().Params().Name: elliptic.P521(),
+}
+
+// jwkToPKIX reconstructs a DER-encoded SubjectPublicKeyInfo from a JWK's
+// type-specific fields (RSA "n"/"e" or EC "crv"/"x"/"y"), the reverse of the
+// encoding BundleToDocument performs.
+func jwkToPKIX(key bundleDocumentKey) ([]byte, error) {
+	switch key.Kty {
+	case "RSA":
+		if key.N == "" || key.E == "" {
+			return nil, fmt.Errorf("RSA jwt-svid key is missing n or e")
+		}
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode n: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode e: %w", err)
+		}
+		pub := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		return x509.MarshalPKIXPublicKey(pub)
+	case "EC":
+		curve, ok := ellipticCurves[key.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported EC curve %q", key.Crv)
+		}
+		if key.X == "" || key.Y == "" {
+			return nil, fmt.Errorf("EC jwt-svid key is missing x or y")
+		}
+		x, err := base64.RawURLEncoding.DecodeString(key.X)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode x: %w", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(key.Y)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode y: %w", err)
+		}
+		pub := &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		return x509.MarshalPKIXPublicKey(pub)
+	default:
+		return nil, fmt.Errorf("unsupported jwt-svid key type %q", key.Kty)
+	}
+}
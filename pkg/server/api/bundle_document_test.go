@@ -0,0 +1,129 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/spiffe/spire/proto/spire/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundleToDocumentAndBackRoundTripsRSAAndEC(t *testing.T) {
+	caDER := selfSignedCADER(t)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaPKIX, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecPKIX, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	require.NoError(t, err)
+
+	bundle := &common.Bundle{
+		TrustDomainId:  "spiffe://example.org",
+		RefreshHint:    60,
+		SequenceNumber: 42,
+		RootCas: []*common.Certificate{
+			{DerBytes: caDER},
+		},
+		JwtSigningKeys: []*common.PublicKey{
+			{Kid: "rsa-key-1", PkixBytes: rsaPKIX},
+			{Kid: "ec-key-1", PkixBytes: ecPKIX},
+		},
+	}
+
+	doc, err := BundleToDocument(bundle)
+	require.NoError(t, err)
+
+	got, err := DocumentToBundle("spiffe://example.org", doc)
+	require.NoError(t, err)
+
+	require.Equal(t, bundle.RefreshHint, got.RefreshHint)
+	require.Equal(t, bundle.SequenceNumber, got.SequenceNumber)
+	require.Len(t, got.RootCas, 1)
+	require.Equal(t, caDER, got.RootCas[0].DerBytes)
+
+	require.Len(t, got.JwtSigningKeys, 2)
+	require.Equal(t, "rsa-key-1", got.JwtSigningKeys[0].Kid)
+	require.Equal(t, rsaPKIX, got.JwtSigningKeys[0].PkixBytes)
+	require.Equal(t, "ec-key-1", got.JwtSigningKeys[1].Kid)
+	require.Equal(t, ecPKIX, got.JwtSigningKeys[1].PkixBytes)
+}
+
+func TestBundleToDocumentRejectsUnparseableJWTKey(t *testing.T) {
+	bundle := &common.Bundle{
+		TrustDomainId: "spiffe://example.org",
+		JwtSigningKeys: []*common.PublicKey{
+			{Kid: "bad-key", PkixBytes: []byte("not a key")},
+		},
+	}
+
+	_, err := BundleToDocument(bundle)
+	require.Error(t, err)
+}
+
+func TestDocumentToBundleRejectsNonCACertificate(t *testing.T) {
+	leafDER := leafCertDER(t)
+	doc := `{"keys":[{"use":"x509-svid","x5c":["` + base64.StdEncoding.EncodeToString(leafDER) + `"]}]}`
+
+	_, err := DocumentToBundle("spiffe://example.org", []byte(doc))
+	require.ErrorContains(t, err, "not a CA certificate")
+}
+
+func TestDocumentToBundleRejectsUnsupportedKeyUse(t *testing.T) {
+	_, err := DocumentToBundle("spiffe://example.org", []byte(`{"keys":[{"use":"bogus"}]}`))
+	require.ErrorContains(t, err, "unsupported key use")
+}
+
+func TestDocumentToBundleRejectsUnsupportedECCurve(t *testing.T) {
+	doc := `{"keys":[{"use":"jwt-svid","kid":"k1","kty":"EC","crv":"P-999","x":"AA","y":"AA"}]}`
+
+	_, err := DocumentToBundle("spiffe://example.org", []byte(doc))
+	require.ErrorContains(t, err, "unsupported EC curve")
+}
+
+func selfSignedCADER(t *testing.T) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	return der
+}
+
+func leafCertDER(t *testing.T) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "test leaf"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         false,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	return der
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/spiffe/spire/pkg/server/plugin/datastore"
+)
+
+// ProtoToFederationRelationship converts the given proto federation
+// relationship into its datastore representation.
+func ProtoToFederationRelationship(r *types.FederationRelationship) (*datastore.FederationRelationship, error) {
+	if r == nil {
+		return nil, fmt.Errorf("federation relationship is nil")
+	}
+
+	td, err := spiffeid.TrustDomainFromString(r.TrustDomain)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trust domain: %w", err)
+	}
+
+	fr := &datastore.FederationRelationship{
+		TrustDomain:       td,
+		BundleEndpointURL: r.BundleEndpointUrl,
+	}
+
+	switch profile := r.BundleEndpointProfile.(type) {
+	case *types.FederationRelationship_HttpsWeb:
+		fr.BundleEndpointProfile = datastore.BundleEndpointProfileHTTPSWeb
+	case *types.FederationRelationship_HttpsSpiffe:
+		fr.BundleEndpointProfile = datastore.BundleEndpointProfileHTTPSSPIFFE
+		id, err := spiffeid.FromString(profile.HttpsSpiffe.EndpointSpiffeId)
+		if err != nil {
+			return nil, fmt.Errorf("invalid endpoint SPIFFE ID: %w", err)
+		}
+		fr.EndpointSPIFFEID = id
+	default:
+		return nil, fmt.Errorf("unsupported bundle endpoint profile type %T", profile)
+	}
+
+	if r.TrustDomainBundle != nil {
+		bundle, err := ProtoToBundle(r.TrustDomainBundle)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trust domain bundle: %w", err)
+		}
+		fr.TrustDomainBundle = bundle
+	}
+
+	return fr, nil
+}
+
+// FederationRelationshipToProto converts the given datastore federation
+// relationship into its proto representation. outputMask, when non-nil,
+// limits which fields are populated on the returned message.
+func FederationRelationshipToProto(fr *datastore.FederationRelationship, outputMask *types.FederationRelationshipMask) (*types.FederationRelationship, error) {
+	if fr == nil {
+		return nil, fmt.Errorf("federation relationship is nil")
+	}
+
+	r := &types.FederationRelationship{
+		TrustDomain: fr.TrustDomain.String(),
+	}
+
+	if outputMask == nil || outputMask.BundleEndpointUrl {
+		r.BundleEndpointUrl = fr.BundleEndpointURL
+	}
+
+	if outputMask == nil || outputMask.BundleEndpointProfile {
+		switch fr.BundleEndpointProfile {
+		case datastore.BundleEndpointProfileHTTPSWeb:
+			r.BundleEndpointProfile = &types.FederationRelationship_HttpsWeb{}
+		case datastore.BundleEndpointProfileHTTPSSPIFFE:
+			r.BundleEndpointProfile = &types.FederationRelationship_HttpsSpiffe{
+				HttpsSpiffe: &types.HTTPSSPIFFEProfile{
+					EndpointSpiffeId: fr.EndpointSPIFFEID.String(),
+				},
+			}
+		default:
+			return nil, fmt.Errorf("unsupported bundle endpoint profile %q", fr.BundleEndpointProfile)
+		}
+	}
+
+	if (outputMask == nil || outputMask.TrustDomainBundle) && fr.TrustDomainBundle != nil {
+		bundle, err := BundleToProto(fr.TrustDomainBundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert trust domain bundle: %w", err)
+		}
+		r.TrustDomainBundle = bundle
+	}
+
+	return r, nil
+}
@@ -0,0 +1,378 @@
+package trustdomain
+
+import (
+	"context"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	bundlev1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/bundle/v1"
+	trustdomainv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/trustdomain/v1"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	"github.com/spiffe/spire/pkg/server/api"
+	"github.com/spiffe/spire/pkg/server/api/rpccontext"
+	"github.com/spiffe/spire/pkg/server/plugin/datastore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BundleSetter is the subset of the bundle v1 service used to keep a
+// relationship's bootstrap bundle in sync with the federated bundle store.
+type BundleSetter interface {
+	BatchSetFederatedBundle(ctx context.Context, req *bundlev1.BatchSetFederatedBundleRequest) (*bundlev1.BatchSetFederatedBundleResponse, error)
+}
+
+// Config defines the trust domain service configuration.
+type Config struct {
+	DataStore    datastore.DataStore
+	TrustDomain  spiffeid.TrustDomain
+	BundleSetter BundleSetter
+}
+
+// Service defines the v1 trust domain service properties.
+type Service struct {
+	trustdomainv1.UnsafeTrustDomainServer
+
+	ds     datastore.DataStore
+	td     spiffeid.TrustDomain
+	bundle BundleSetter
+}
+
+// New creates a new trust domain service.
+func New(config Config) *Service {
+	return &Service{
+		ds:     config.DataStore,
+		td:     config.TrustDomain,
+		bundle: config.BundleSetter,
+	}
+}
+
+// RegisterService registers the trust domain service on the gRPC server.
+func RegisterService(s *grpc.Server, service *Service) {
+	trustdomainv1.RegisterTrustDomainServer(s, service)
+}
+
+// ListFederationRelationships returns an optionally paginated list of
+// federation relationships.
+func (s *Service) ListFederationRelationships(ctx context.Context, req *trustdomainv1.ListFederationRelationshipsRequest) (*trustdomainv1.ListFederationRelationshipsResponse, error) {
+	log := rpccontext.Logger(ctx)
+
+	listReq := &datastore.ListFederationRelationshipsRequest{}
+	if req.PageSize > 0 {
+		listReq.Pagination = &datastore.Pagination{
+			PageSize: req.PageSize,
+			Token:    req.PageToken,
+		}
+	}
+
+	dsResp, err := s.ds.ListFederationRelationships(ctx, listReq)
+	if err != nil {
+		return nil, api.MakeErr(log, codes.Internal, "failed to list federation relationships", err)
+	}
+
+	resp := &trustdomainv1.ListFederationRelationshipsResponse{}
+	if dsResp.Pagination != nil {
+		resp.NextPageToken = dsResp.Pagination.Token
+	}
+
+	for _, dsRelationship := range dsResp.FederationRelationships {
+		r, err := api.FederationRelationshipToProto(dsRelationship, req.OutputMask)
+		if err != nil {
+			log = log.WithField(telemetry.TrustDomainID, dsRelationship.TrustDomain.String())
+			return nil, api.MakeErr(log, codes.Internal, "failed to convert federation relationship", err)
+		}
+		resp.FederationRelationships = append(resp.FederationRelationships, r)
+	}
+
+	return resp, nil
+}
+
+// GetFederationRelationship returns the federation relationship for the
+// given trust domain.
+func (s *Service) GetFederationRelationship(ctx context.Context, req *trustdomainv1.GetFederationRelationshipRequest) (*types.FederationRelationship, error) {
+	log := rpccontext.Logger(ctx).WithField(telemetry.TrustDomainID, req.TrustDomain)
+
+	td, err := spiffeid.TrustDomainFromString(req.TrustDomain)
+	if err != nil {
+		return nil, api.MakeErr(log, codes.InvalidArgument, "trust domain argument is not valid", err)
+	}
+
+	dsResp, err := s.ds.FetchFederationRelationship(ctx, &datastore.FetchFederationRelationshipRequest{
+		TrustDomain: td,
+	})
+	if err != nil {
+		return nil, api.MakeErr(log, codes.Internal, "failed to fetch federation relationship", err)
+	}
+
+	if dsResp.FederationRelationship == nil {
+		return nil, api.MakeErr(log, codes.NotFound, "federation relationship not found", nil)
+	}
+
+	r, err := api.FederationRelationshipToProto(dsResp.FederationRelationship, req.OutputMask)
+	if err != nil {
+		return nil, api.MakeErr(log, codes.Internal, "failed to convert federation relationship", err)
+	}
+
+	return r, nil
+}
+
+// BatchCreateFederationRelationship adds one or more federation
+// relationships to the server.
+func (s *Service) BatchCreateFederationRelationship(ctx context.Context, req *trustdomainv1.BatchCreateFederationRelationshipRequest) (*trustdomainv1.BatchCreateFederationRelationshipResponse, error) {
+	var results []*trustdomainv1.BatchCreateFederationRelationshipResponse_Result
+	for _, r := range req.FederationRelationships {
+		results = append(results, s.createFederationRelationship(ctx, r, req.OutputMask))
+	}
+
+	return &trustdomainv1.BatchCreateFederationRelationshipResponse{
+		Results: results,
+	}, nil
+}
+
+func (s *Service) createFederationRelationship(ctx context.Context, r *types.FederationRelationship, outputMask *types.FederationRelationshipMask) *trustdomainv1.BatchCreateFederationRelationshipResponse_Result {
+	log := rpccontext.Logger(ctx).WithField(telemetry.TrustDomainID, r.TrustDomain)
+
+	dsRelationship, err := api.ProtoToFederationRelationship(r)
+	if err != nil {
+		return &trustdomainv1.BatchCreateFederationRelationshipResponse_Result{
+			Status: api.MakeStatus(log, codes.InvalidArgument, "invalid federation relationship", err),
+		}
+	}
+
+	if s.td.Compare(dsRelationship.TrustDomain) == 0 {
+		return &trustdomainv1.BatchCreateFederationRelationshipResponse_Result{
+			Status: api.MakeStatus(log, codes.InvalidArgument, "creating a federation relationship for the server's own trust domain is not allowed", nil),
+		}
+	}
+
+	if dsRelationship.TrustDomainBundle != nil {
+		if err := s.setBootstrapBundle(ctx, r.TrustDomain, r.TrustDomainBundle); err != nil {
+			return &trustdomainv1.BatchCreateFederationRelationshipResponse_Result{
+				Status: api.MakeStatus(log, codes.Internal, "failed to set bootstrap bundle", err),
+			}
+		}
+	}
+
+	dsResp, err := s.ds.CreateFederationRelationship(ctx, &datastore.CreateFederationRelationshipRequest{
+		FederationRelationship: dsRelationship,
+	})
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.AlreadyExists:
+		return &trustdomainv1.BatchCreateFederationRelationshipResponse_Result{
+			Status: api.MakeStatus(log, codes.AlreadyExists, "federation relationship already exists", nil),
+		}
+	default:
+		return &trustdomainv1.BatchCreateFederationRelationshipResponse_Result{
+			Status: api.MakeStatus(log, codes.Internal, "unable to create federation relationship", err),
+		}
+	}
+
+	protoRelationship, err := api.FederationRelationshipToProto(dsResp.FederationRelationship, outputMask)
+	if err != nil {
+		return &trustdomainv1.BatchCreateFederationRelationshipResponse_Result{
+			Status: api.MakeStatus(log, codes.Internal, "failed to convert federation relationship", err),
+		}
+	}
+
+	log.Debug("Federation relationship created")
+	return &trustdomainv1.BatchCreateFederationRelationshipResponse_Result{
+		Status:                 api.OK(),
+		FederationRelationship: protoRelationship,
+	}
+}
+
+// BatchUpdateFederationRelationship updates one or more federation
+// relationships in the server.
+func (s *Service) BatchUpdateFederationRelationship(ctx context.Context, req *trustdomainv1.BatchUpdateFederationRelationshipRequest) (*trustdomainv1.BatchUpdateFederationRelationshipResponse, error) {
+	var results []*trustdomainv1.BatchUpdateFederationRelationshipResponse_Result
+	for _, r := range req.FederationRelationships {
+		results = append(results, s.updateFederationRelationship(ctx, r, req.OutputMask))
+	}
+
+	return &trustdomainv1.BatchUpdateFederationRelationshipResponse{
+		Results: results,
+	}, nil
+}
+
+func (s *Service) updateFederationRelationship(ctx context.Context, r *types.FederationRelationship, outputMask *types.FederationRelationshipMask) *trustdomainv1.BatchUpdateFederationRelationshipResponse_Result {
+	log := rpccontext.Logger(ctx).WithField(telemetry.TrustDomainID, r.TrustDomain)
+
+	dsRelationship, err := api.ProtoToFederationRelationship(r)
+	if err != nil {
+		return &trustdomainv1.BatchUpdateFederationRelationshipResponse_Result{
+			Status: api.MakeStatus(log, codes.InvalidArgument, "invalid federation relationship", err),
+		}
+	}
+
+	if s.td.Compare(dsRelationship.TrustDomain) == 0 {
+		return &trustdomainv1.BatchUpdateFederationRelationshipResponse_Result{
+			Status: api.MakeStatus(log, codes.InvalidArgument, "updating a federation relationship for the server's own trust domain is not allowed", nil),
+		}
+	}
+
+	if dsRelationship.TrustDomainBundle != nil {
+		if err := s.setBootstrapBundle(ctx, r.TrustDomain, r.TrustDomainBundle); err != nil {
+			return &trustdomainv1.BatchUpdateFederationRelationshipResponse_Result{
+				Status: api.MakeStatus(log, codes.Internal, "failed to set bootstrap bundle", err),
+			}
+		}
+	}
+
+	dsResp, err := s.ds.UpdateFederationRelationship(ctx, &datastore.UpdateFederationRelationshipRequest{
+		FederationRelationship: dsRelationship,
+	})
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.NotFound:
+		return &trustdomainv1.BatchUpdateFederationRelationshipResponse_Result{
+			Status: api.MakeStatus(log, codes.NotFound, "federation relationship not found", nil),
+		}
+	default:
+		return &trustdomainv1.BatchUpdateFederationRelationshipResponse_Result{
+			Status: api.MakeStatus(log, codes.Internal, "unable to update federation relationship", err),
+		}
+	}
+
+	protoRelationship, err := api.FederationRelationshipToProto(dsResp.FederationRelationship, outputMask)
+	if err != nil {
+		return &trustdomainv1.BatchUpdateFederationRelationshipResponse_Result{
+			Status: api.MakeStatus(log, codes.Internal, "failed to convert federation relationship", err),
+		}
+	}
+
+	log.Debug("Federation relationship updated")
+	return &trustdomainv1.BatchUpdateFederationRelationshipResponse_Result{
+		Status:                 api.OK(),
+		FederationRelationship: protoRelationship,
+	}
+}
+
+// BatchSetFederationRelationship upserts one or more federation
+// relationships in the server.
+func (s *Service) BatchSetFederationRelationship(ctx context.Context, req *trustdomainv1.BatchSetFederationRelationshipRequest) (*trustdomainv1.BatchSetFederationRelationshipResponse, error) {
+	var results []*trustdomainv1.BatchSetFederationRelationshipResponse_Result
+	for _, r := range req.FederationRelationships {
+		results = append(results, s.setFederationRelationship(ctx, r, req.OutputMask))
+	}
+
+	return &trustdomainv1.BatchSetFederationRelationshipResponse{
+		Results: results,
+	}, nil
+}
+
+func (s *Service) setFederationRelationship(ctx context.Context, r *types.FederationRelationship, outputMask *types.FederationRelationshipMask) *trustdomainv1.BatchSetFederationRelationshipResponse_Result {
+	log := rpccontext.Logger(ctx).WithField(telemetry.TrustDomainID, r.TrustDomain)
+
+	dsRelationship, err := api.ProtoToFederationRelationship(r)
+	if err != nil {
+		return &trustdomainv1.BatchSetFederationRelationshipResponse_Result{
+			Status: api.MakeStatus(log, codes.InvalidArgument, "invalid federation relationship", err),
+		}
+	}
+
+	if s.td.Compare(dsRelationship.TrustDomain) == 0 {
+		return &trustdomainv1.BatchSetFederationRelationshipResponse_Result{
+			Status: api.MakeStatus(log, codes.InvalidArgument, "setting a federation relationship for the server's own trust domain is not allowed", nil),
+		}
+	}
+
+	if dsRelationship.TrustDomainBundle != nil {
+		if err := s.setBootstrapBundle(ctx, r.TrustDomain, r.TrustDomainBundle); err != nil {
+			return &trustdomainv1.BatchSetFederationRelationshipResponse_Result{
+				Status: api.MakeStatus(log, codes.Internal, "failed to set bootstrap bundle", err),
+			}
+		}
+	}
+
+	dsResp, err := s.ds.SetFederationRelationship(ctx, &datastore.SetFederationRelationshipRequest{
+		FederationRelationship: dsRelationship,
+	})
+	if err != nil {
+		return &trustdomainv1.BatchSetFederationRelationshipResponse_Result{
+			Status: api.MakeStatus(log, codes.Internal, "failed to set federation relationship", err),
+		}
+	}
+
+	protoRelationship, err := api.FederationRelationshipToProto(dsResp.FederationRelationship, outputMask)
+	if err != nil {
+		return &trustdomainv1.BatchSetFederationRelationshipResponse_Result{
+			Status: api.MakeStatus(log, codes.Internal, "failed to convert federation relationship", err),
+		}
+	}
+
+	log.Debug("Federation relationship set")
+	return &trustdomainv1.BatchSetFederationRelationshipResponse_Result{
+		Status:                 api.OK(),
+		FederationRelationship: protoRelationship,
+	}
+}
+
+// BatchDeleteFederationRelationship removes one or more federation
+// relationships from the server.
+func (s *Service) BatchDeleteFederationRelationship(ctx context.Context, req *trustdomainv1.BatchDeleteFederationRelationshipRequest) (*trustdomainv1.BatchDeleteFederationRelationshipResponse, error) {
+	var results []*trustdomainv1.BatchDeleteFederationRelationshipResponse_Result
+	for _, trustDomain := range req.TrustDomains {
+		results = append(results, s.deleteFederationRelationship(ctx, trustDomain))
+	}
+
+	return &trustdomainv1.BatchDeleteFederationRelationshipResponse{
+		Results: results,
+	}, nil
+}
+
+func (s *Service) deleteFederationRelationship(ctx context.Context, trustDomain string) *trustdomainv1.BatchDeleteFederationRelationshipResponse_Result {
+	log := rpccontext.Logger(ctx).WithField(telemetry.TrustDomainID, trustDomain)
+
+	td, err := spiffeid.TrustDomainFromString(trustDomain)
+	if err != nil {
+		return &trustdomainv1.BatchDeleteFederationRelationshipResponse_Result{
+			TrustDomain: trustDomain,
+			Status:      api.MakeStatus(log, codes.InvalidArgument, "trust domain argument is not valid", err),
+		}
+	}
+
+	err = s.ds.DeleteFederationRelationship(ctx, &datastore.DeleteFederationRelationshipRequest{
+		TrustDomain: td,
+	})
+
+	switch status.Code(err) {
+	case codes.OK:
+		return &trustdomainv1.BatchDeleteFederationRelationshipResponse_Result{
+			TrustDomain: trustDomain,
+			Status:      api.OK(),
+		}
+	case codes.NotFound:
+		return &trustdomainv1.BatchDeleteFederationRelationshipResponse_Result{
+			TrustDomain: trustDomain,
+			Status:      api.MakeStatus(log, codes.NotFound, "federation relationship not found", nil),
+		}
+	default:
+		return &trustdomainv1.BatchDeleteFederationRelationshipResponse_Result{
+			TrustDomain: trustDomain,
+			Status:      api.MakeStatus(log, codes.Internal, "failed to delete federation relationship", err),
+		}
+	}
+}
+
+// setBootstrapBundle keeps the federated bundle store consistent with a
+// relationship's inline bootstrap bundle by routing it through the bundle
+// v1 service, the same way a CLI-driven BatchSetFederatedBundle call would.
+func (s *Service) setBootstrapBundle(ctx context.Context, trustDomain string, bundle *types.Bundle) error {
+	if s.bundle == nil {
+		return nil
+	}
+
+	resp, err := s.bundle.BatchSetFederatedBundle(ctx, &bundlev1.BatchSetFederatedBundleRequest{
+		Bundle: []*types.Bundle{bundle},
+	})
+	if err != nil {
+		return err
+	}
+	for _, result := range resp.Results {
+		if result.Status.Code != int32(codes.OK) {
+			return status.Errorf(codes.Code(result.Status.Code), "failed to set bootstrap bundle for %q: %s", trustDomain, result.Status.Message)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package trustdomain
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	trustdomainv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/trustdomain/v1"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/spiffe/spire/pkg/server/plugin/datastore"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	testServerTrustDomain     = "spiffe://example.org"
+	testFederationRelationTD  = "federated.test"
+	httpsWebBundleEndpointURL = "https://federated.test/bundle"
+)
+
+func TestBatchCreateFederationRelationshipRejectsOwnTrustDomain(t *testing.T) {
+	ds := newFakeRelationshipDataStore()
+	service := newTestService(t, ds, nil)
+
+	resp, err := service.BatchCreateFederationRelationship(context.Background(), &trustdomainv1.BatchCreateFederationRelationshipRequest{
+		FederationRelationships: []*types.FederationRelationship{httpsWebRelationship(testServerTrustDomain)},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 1)
+	require.Equal(t, int32(codes.InvalidArgument), resp.Results[0].Status.Code)
+}
+
+func TestBatchCreateFederationRelationshipSucceeds(t *testing.T) {
+	ds := newFakeRelationshipDataStore()
+	service := newTestService(t, ds, nil)
+
+	resp, err := service.BatchCreateFederationRelationship(context.Background(), &trustdomainv1.BatchCreateFederationRelationshipRequest{
+		FederationRelationships: []*types.FederationRelationship{httpsWebRelationship(testFederationRelationTD)},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 1)
+	require.Equal(t, int32(codes.OK), resp.Results[0].Status.Code)
+	require.Contains(t, ds.relationships, testFederationRelationTD)
+}
+
+func TestBatchUpdateFederationRelationshipRejectsOwnTrustDomain(t *testing.T) {
+	ds := newFakeRelationshipDataStore()
+	service := newTestService(t, ds, nil)
+
+	resp, err := service.BatchUpdateFederationRelationship(context.Background(), &trustdomainv1.BatchUpdateFederationRelationshipRequest{
+		FederationRelationships: []*types.FederationRelationship{httpsWebRelationship(testServerTrustDomain)},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 1)
+	require.Equal(t, int32(codes.InvalidArgument), resp.Results[0].Status.Code)
+}
+
+func TestBatchSetFederationRelationshipRejectsOwnTrustDomain(t *testing.T) {
+	ds := newFakeRelationshipDataStore()
+	service := newTestService(t, ds, nil)
+
+	resp, err := service.BatchSetFederationRelationship(context.Background(), &trustdomainv1.BatchSetFederationRelationshipRequest{
+		FederationRelationships: []*types.FederationRelationship{httpsWebRelationship(testServerTrustDomain)},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 1)
+	require.Equal(t, int32(codes.InvalidArgument), resp.Results[0].Status.Code)
+}
+
+func TestGetFederationRelationshipNotFound(t *testing.T) {
+	ds := newFakeRelationshipDataStore()
+	service := newTestService(t, ds, nil)
+
+	_, err := service.GetFederationRelationship(context.Background(), &trustdomainv1.GetFederationRelationshipRequest{
+		TrustDomain: testFederationRelationTD,
+	})
+	require.Error(t, err)
+}
+
+func TestBatchDeleteFederationRelationship(t *testing.T) {
+	ds := newFakeRelationshipDataStore()
+	ds.relationships[testFederationRelationTD] = &datastore.FederationRelationship{}
+	service := newTestService(t, ds, nil)
+
+	resp, err := service.BatchDeleteFederationRelationship(context.Background(), &trustdomainv1.BatchDeleteFederationRelationshipRequest{
+		TrustDomains: []string{testFederationRelationTD},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 1)
+	require.Equal(t, int32(codes.OK), resp.Results[0].Status.Code)
+	require.NotContains(t, ds.relationships, testFederationRelationTD)
+}
+
+func newTestService(t *testing.T, ds datastore.DataStore, bundleSetter BundleSetter) *Service {
+	t.Helper()
+	td, err := spiffeid.TrustDomainFromString(testServerTrustDomain)
+	require.NoError(t, err)
+	return New(Config{
+		DataStore:    ds,
+		TrustDomain:  td,
+		BundleSetter: bundleSetter,
+	})
+}
+
+func httpsWebRelationship(trustDomain string) *types.FederationRelationship {
+	return &types.FederationRelationship{
+		TrustDomain:           trustDomain,
+		BundleEndpointUrl:     httpsWebBundleEndpointURL,
+		BundleEndpointProfile: &types.FederationRelationship_HttpsWeb{},
+	}
+}
+
+// fakeRelationshipDataStore is a minimal in-memory datastore.DataStore for
+// exercising the trust domain service without a real plugin backend.
+type fakeRelationshipDataStore struct {
+	mu            sync.Mutex
+	relationships map[string]*datastore.FederationRelationship
+}
+
+func newFakeRelationshipDataStore() *fakeRelationshipDataStore {
+	return &fakeRelationshipDataStore{relationships: make(map[string]*datastore.FederationRelationship)}
+}
+
+func (f *fakeRelationshipDataStore) CountBundles(ctx context.Context) (int32, error) {
+	return 0, nil
+}
+
+func (f *fakeRelationshipDataStore) FetchBundle(ctx context.Context, req *datastore.FetchBundleRequest) (*datastore.FetchBundleResponse, error) {
+	return &datastore.FetchBundleResponse{}, nil
+}
+
+func (f *fakeRelationshipDataStore) ListBundles(ctx context.Context, req *datastore.ListBundlesRequest) (*datastore.ListBundlesResponse, error) {
+	return &datastore.ListBundlesResponse{}, nil
+}
+
+func (f *fakeRelationshipDataStore) CreateBundle(ctx context.Context, req *datastore.CreateBundleRequest) (*datastore.CreateBundleResponse, error) {
+	return &datastore.CreateBundleResponse{}, nil
+}
+
+func (f *fakeRelationshipDataStore) AppendBundle(ctx context.Context, req *datastore.AppendBundleRequest) (*datastore.AppendBundleResponse, error) {
+	return &datastore.AppendBundleResponse{}, nil
+}
+
+func (f *fakeRelationshipDataStore) SetBundle(ctx context.Context, req *datastore.SetBundleRequest) (*datastore.SetBundleResponse, error) {
+	return &datastore.SetBundleResponse{}, nil
+}
+
+func (f *fakeRelationshipDataStore) UpdateBundle(ctx context.Context, req *datastore.UpdateBundleRequest) (*datastore.UpdateBundleResponse, error) {
+	return &datastore.UpdateBundleResponse{}, nil
+}
+
+func (f *fakeRelationshipDataStore) DeleteBundle(ctx context.Context, req *datastore.DeleteBundleRequest) (*datastore.DeleteBundleResponse, error) {
+	return &datastore.DeleteBundleResponse{}, nil
+}
+
+func (f *fakeRelationshipDataStore) FetchFederationRelationship(ctx context.Context, req *datastore.FetchFederationRelationshipRequest) (*datastore.FetchFederationRelationshipResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &datastore.FetchFederationRelationshipResponse{FederationRelationship: f.relationships[req.TrustDomain.String()]}, nil
+}
+
+func (f *fakeRelationshipDataStore) ListFederationRelationships(ctx context.Context, req *datastore.ListFederationRelationshipsRequest) (*datastore.ListFederationRelationshipsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &datastore.ListFederationRelationshipsResponse{}
+	for _, r := range f.relationships {
+		resp.FederationRelationships = append(resp.FederationRelationships, r)
+	}
+	return resp, nil
+}
+
+func (f *fakeRelationshipDataStore) CreateFederationRelationship(ctx context.Context, req *datastore.CreateFederationRelationshipRequest) (*datastore.CreateFederationRelationshipResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.relationships[req.FederationRelationship.TrustDomain.String()] = req.FederationRelationship
+	return &datastore.CreateFederationRelationshipResponse{FederationRelationship: req.FederationRelationship}, nil
+}
+
+func (f *fakeRelationshipDataStore) UpdateFederationRelationship(ctx context.Context, req *datastore.UpdateFederationRelationshipRequest) (*datastore.UpdateFederationRelationshipResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.relationships[req.FederationRelationship.TrustDomain.String()] = req.FederationRelationship
+	return &datastore.UpdateFederationRelationshipResponse{FederationRelationship: req.FederationRelationship}, nil
+}
+
+func (f *fakeRelationshipDataStore) SetFederationRelationship(ctx context.Context, req *datastore.SetFederationRelationshipRequest) (*datastore.SetFederationRelationshipResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.relationships[req.FederationRelationship.TrustDomain.String()] = req.FederationRelationship
+	return &datastore.SetFederationRelationshipResponse{FederationRelationship: req.FederationRelationship}, nil
+}
+
+func (f *fakeRelationshipDataStore) DeleteFederationRelationship(ctx context.Context, req *datastore.DeleteFederationRelationshipRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.relationships, req.TrustDomain.String())
+	return nil
+}
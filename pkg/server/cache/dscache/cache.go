@@ -0,0 +1,19 @@
+package dscache
+
+import "context"
+
+type cacheKey struct{}
+
+// WithCache marks ctx so that a FetchBundle routed through it may be served
+// from the request-scoped read-through cache instead of always going to the
+// datastore plugin. It's used on read paths (like GetBundle) that are hit
+// once per incoming RPC and don't need a fresh read each time.
+func WithCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheKey{}, true)
+}
+
+// Cached reports whether ctx was marked by WithCache.
+func Cached(ctx context.Context) bool {
+	cached, _ := ctx.Value(cacheKey{}).(bool)
+	return cached
+}
@@ -0,0 +1,185 @@
+package dscache
+
+import (
+	"sync"
+
+	"github.com/spiffe/spire/pkg/server/plugin/datastore"
+)
+
+// FederatedBundleWatchHub fans out federated bundle change events to
+// registered watchers. Each watcher supplies a set of trust domain filters;
+// the hub only wakes watchers whose filter intersects the trust domain that
+// changed, so a server federated with hundreds of trust domains doesn't pay
+// for watchers that only care about one of them.
+//
+// The hub is driven by calling Notify once a bundle change has been
+// committed to the datastore; the ModifiedIndex on the event must be the
+// value the datastore assigned the write (e.g. SetBundleResponse.
+// ModifiedIndex), not one minted by the hub. That way the index survives a
+// process restart and reflects a true datastore-level rollback (such as a
+// restore from an older backup), which a hub-local counter never could.
+type FederatedBundleWatchHub struct {
+	mu            sync.Mutex
+	lastIndex     uint64
+	byTrustDomain map[string]map[*federatedBundleWatcher]struct{}
+	all           map[*federatedBundleWatcher]struct{}
+}
+
+// NewFederatedBundleWatchHub creates an empty watch hub.
+func NewFederatedBundleWatchHub() *FederatedBundleWatchHub {
+	return &FederatedBundleWatchHub{
+		byTrustDomain: make(map[string]map[*federatedBundleWatcher]struct{}),
+		all:           make(map[*federatedBundleWatcher]struct{}),
+	}
+}
+
+// FederatedBundleWatcher is returned by Watch and delivers events to the
+// caller until Close is called.
+type FederatedBundleWatcher interface {
+	// Events returns the channel events are delivered on.
+	Events() <-chan datastore.BundleEvent
+	// Dropped reports whether an event was dropped for this watcher since
+	// the last call to Dropped (because its buffered channel was full when
+	// Notify tried to deliver), and clears the flag. This catches a gap a
+	// ModifiedIndex comparison alone can't: a forward skip past events that
+	// were actually destined for this watcher, as opposed to a trust domain
+	// it was simply filtered away from (which never reaches the channel and
+	// so can't be mistaken for a drop either way).
+	Dropped() bool
+	// Close unregisters the watcher from the hub.
+	Close()
+}
+
+type federatedBundleWatcher struct {
+	hub    *FederatedBundleWatchHub
+	filter map[string]struct{} // empty means "all trust domains"
+	events chan datastore.BundleEvent
+
+	mu      sync.Mutex
+	dropped bool
+}
+
+func (w *federatedBundleWatcher) Events() <-chan datastore.BundleEvent { return w.events }
+
+func (w *federatedBundleWatcher) Dropped() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	dropped := w.dropped
+	w.dropped = false
+	return dropped
+}
+
+func (w *federatedBundleWatcher) markDropped() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.dropped = true
+}
+
+func (w *federatedBundleWatcher) Close() {
+	w.hub.mu.Lock()
+	defer w.hub.mu.Unlock()
+
+	if len(w.filter) == 0 {
+		delete(w.hub.all, w)
+	} else {
+		for td := range w.filter {
+			set := w.hub.byTrustDomain[td]
+			delete(set, w)
+			if len(set) == 0 {
+				delete(w.hub.byTrustDomain, td)
+			}
+		}
+	}
+	close(w.events)
+}
+
+// Watch registers a new watcher for the given trust domain filters (an empty
+// filter matches every trust domain) and returns it along with the global
+// index at registration time. That index is what a caller should compare
+// against a resume point (e.g. WatchFederatedBundlesRequest.SinceIndex): if
+// they match, every event from here on is delivered on the returned
+// watcher's channel with nothing missed in between, so the caller can skip
+// re-sending a full snapshot. Callers that do take a snapshot are expected
+// to take it under the hub's lock (see Snapshot), the lock Notify holds
+// while it records an event's ModifiedIndex, so the snapshot and the first
+// delivered event are consistent; see the bundle v1
+// service's WatchFederatedBundles for that handshake.
+func (h *FederatedBundleWatchHub) Watch(trustDomains []string) (FederatedBundleWatcher, uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	w := &federatedBundleWatcher{
+		hub:    h,
+		events: make(chan datastore.BundleEvent, 16),
+	}
+
+	if len(trustDomains) == 0 {
+		h.all[w] = struct{}{}
+		return w, h.lastIndex
+	}
+
+	w.filter = make(map[string]struct{}, len(trustDomains))
+	for _, td := range trustDomains {
+		w.filter[td] = struct{}{}
+		set, ok := h.byTrustDomain[td]
+		if !ok {
+			set = make(map[*federatedBundleWatcher]struct{})
+			h.byTrustDomain[td] = set
+		}
+		set[w] = struct{}{}
+	}
+	return w, h.lastIndex
+}
+
+// Index returns the ModifiedIndex of the most recent event passed to
+// Notify, as assigned by the datastore.
+func (h *FederatedBundleWatchHub) Index() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.lastIndex
+}
+
+// Snapshot calls fn with the hub's current index, holding the hub's lock for
+// the duration of the call so no Notify can advance the index and no Watch
+// can register while fn runs. A caller building a bundle snapshot to send a
+// new or resynchronizing watcher should list its bundles from inside fn,
+// so the returned index and the listed bundles are guaranteed consistent
+// with each other; listing them outside the lock (e.g. calling Index
+// separately before or after the list) can race a concurrent write and
+// return a snapshot whose index doesn't actually match its contents.
+func (h *FederatedBundleWatchHub) Snapshot(fn func(index uint64)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fn(h.lastIndex)
+}
+
+// Notify delivers event, which must already carry the ModifiedIndex the
+// datastore assigned its write, to every watcher whose filter includes the
+// event's trust domain, plus any watcher registered for all trust domains.
+// A watcher whose channel is full is marked dropped rather than blocking
+// the notifier or being silently skipped; callers must check
+// FederatedBundleWatcher.Dropped() to detect the gap and resynchronize,
+// since a missing ModifiedIndex is not by itself distinguishable from an
+// event the watcher was correctly filtered away from.
+func (h *FederatedBundleWatchHub) Notify(event datastore.BundleEvent) {
+	h.mu.Lock()
+	if event.ModifiedIndex > h.lastIndex {
+		h.lastIndex = event.ModifiedIndex
+	}
+	watchers := make(map[*federatedBundleWatcher]struct{}, len(h.all))
+	for w := range h.all {
+		watchers[w] = struct{}{}
+	}
+	for w := range h.byTrustDomain[event.TrustDomainId] {
+		watchers[w] = struct{}{}
+	}
+	h.mu.Unlock()
+
+	for w := range watchers {
+		select {
+		case w.events <- event:
+		default:
+			w.markDropped()
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package dscache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spiffe/spire/pkg/server/plugin/datastore"
+	"github.com/spiffe/spire/proto/spire/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchAllTrustDomainsReceivesEveryEvent(t *testing.T) {
+	hub := NewFederatedBundleWatchHub()
+
+	watcher, regIndex := hub.Watch(nil)
+	defer watcher.Close()
+	require.Equal(t, uint64(0), regIndex)
+
+	hub.Notify(bundleEvent("td1.org", 1))
+	hub.Notify(bundleEvent("td2.org", 2))
+
+	assertReceives(t, watcher, "td1.org", 1)
+	assertReceives(t, watcher, "td2.org", 2)
+	assert.Equal(t, uint64(2), hub.Index())
+}
+
+func TestWatchFilteredTrustDomainOnlyReceivesMatchingEvents(t *testing.T) {
+	hub := NewFederatedBundleWatchHub()
+
+	watcher, _ := hub.Watch([]string{"td1.org"})
+	defer watcher.Close()
+
+	hub.Notify(bundleEvent("td2.org", 1))
+	hub.Notify(bundleEvent("td1.org", 2))
+
+	assertReceives(t, watcher, "td1.org", 2)
+	assertNoEvent(t, watcher)
+}
+
+func TestWatchReturnsIndexAtRegistrationTime(t *testing.T) {
+	hub := NewFederatedBundleWatchHub()
+
+	first, _ := hub.Watch(nil)
+	hub.Notify(bundleEvent("td1.org", 5))
+	first.Close()
+
+	second, regIndex := hub.Watch(nil)
+	defer second.Close()
+	assert.Equal(t, uint64(5), regIndex)
+}
+
+func TestCloseUnregistersWatcher(t *testing.T) {
+	hub := NewFederatedBundleWatchHub()
+
+	watcher, _ := hub.Watch([]string{"td1.org"})
+	watcher.Close()
+
+	hub.Notify(bundleEvent("td1.org", 1))
+
+	_, ok := <-watcher.Events()
+	assert.False(t, ok, "events channel should be closed")
+}
+
+func TestNotifyMarksWatcherDroppedWhenChannelIsFull(t *testing.T) {
+	hub := NewFederatedBundleWatchHub()
+
+	watcher, _ := hub.Watch(nil)
+	defer watcher.Close()
+
+	// The channel is buffered (see Watch); overflow it
+	// without draining so Notify has no choice but to drop.
+	const capacity = 16
+	for i := 0; i < capacity+1; i++ {
+		hub.Notify(bundleEvent("td1.org", uint64(i+1)))
+	}
+
+	assert.True(t, watcher.Dropped())
+	assert.False(t, watcher.Dropped(), "Dropped should clear the flag after reporting it")
+}
+
+func bundleEvent(trustDomainID string, modifiedIndex uint64) datastore.BundleEvent {
+	return datastore.BundleEvent{
+		Type:          datastore.BundleEventUpdated,
+		TrustDomainId: trustDomainID,
+		Bundle:        &common.Bundle{TrustDomainId: trustDomainID},
+		ModifiedIndex: modifiedIndex,
+	}
+}
+
+func assertReceives(t *testing.T, watcher FederatedBundleWatcher, trustDomainID string, modifiedIndex uint64) {
+	t.Helper()
+	select {
+	case event := <-watcher.Events():
+		assert.Equal(t, trustDomainID, event.TrustDomainId)
+		assert.Equal(t, modifiedIndex, event.ModifiedIndex)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+}
+
+func assertNoEvent(t *testing.T, watcher FederatedBundleWatcher) {
+	t.Helper()
+	select {
+	case event := <-watcher.Events():
+		t.Fatalf("unexpected event delivered: %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
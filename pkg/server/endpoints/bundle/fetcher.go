@@ -0,0 +1,161 @@
+package bundle
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire/pkg/server/api"
+	"github.com/spiffe/spire/pkg/server/plugin/datastore"
+	"github.com/spiffe/spire/proto/spire/common"
+)
+
+// HTTPFetcher is the default EndpointFetcher: it fetches a bundle endpoint
+// over HTTPS, authenticating the server per the relationship's
+// BundleEndpointProfile, and parses the response as a SPIFFE Trust Domain
+// Bundle document.
+type HTTPFetcher struct {
+	// RootCAs authenticates bundle endpoints using the https_web profile.
+	// When nil, the host's root CA set is used.
+	RootCAs *x509.CertPool
+}
+
+// FetchBundle implements EndpointFetcher.
+func (f *HTTPFetcher) FetchBundle(ctx context.Context, relationship *datastore.FederationRelationship) (*common.Bundle, error) {
+	client, err := f.httpClient(relationship)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build client for %q: %w", relationship.TrustDomain, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, relationship.BundleEndpointURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build bundle endpoint request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach bundle endpoint %q: %w", relationship.BundleEndpointURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bundle endpoint %q returned status %d", relationship.BundleEndpointURL, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle endpoint response: %w", err)
+	}
+
+	bundle, err := api.DocumentToBundle(relationship.TrustDomain.IDString(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bundle document from %q: %w", relationship.BundleEndpointURL, err)
+	}
+
+	return bundle, nil
+}
+
+func (f *HTTPFetcher) httpClient(relationship *datastore.FederationRelationship) (*http.Client, error) {
+	switch relationship.BundleEndpointProfile {
+	case datastore.BundleEndpointProfileHTTPSWeb:
+		return &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					RootCAs:    f.RootCAs,
+					MinVersion: tls.VersionTLS12,
+				},
+			},
+		}, nil
+	case datastore.BundleEndpointProfileHTTPSSPIFFE:
+		if relationship.TrustDomainBundle == nil {
+			return nil, fmt.Errorf("no bootstrap bundle is available to authenticate the https_spiffe endpoint")
+		}
+		roots, err := x509AuthoritiesFromBundle(relationship.TrustDomainBundle)
+		if err != nil {
+			return nil, err
+		}
+		expectedID := relationship.EndpointSPIFFEID
+		return &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					MinVersion:         tls.VersionTLS12,
+					InsecureSkipVerify: true, //nolint:gosec // peer identity is verified in VerifyPeerCertificate below
+					VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+						return verifySPIFFEPeer(rawCerts, roots, expectedID)
+					},
+				},
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported bundle endpoint profile %q", relationship.BundleEndpointProfile)
+	}
+}
+
+func x509AuthoritiesFromBundle(bundle *common.Bundle) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for _, rootCA := range bundle.RootCas {
+		cert, err := x509.ParseCertificate(rootCA.DerBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse trust domain bundle authority: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+func verifySPIFFEPeer(rawCerts [][]byte, roots []*x509.Certificate, expectedID spiffeid.ID) error {
+	if len(rawCerts) == 0 {
+		return fmt.Errorf("no peer certificate presented")
+	}
+
+	leaf, err := x509.ParseCertificate(rawCerts[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse peer certificate: %w", err)
+	}
+
+	rootPool := x509.NewCertPool()
+	for _, root := range roots {
+		rootPool.AddCert(root)
+	}
+
+	// The peer may chain through an intermediate CA rather than being
+	// signed directly by a bundle root; rawCerts[1:] is whatever chain the
+	// peer presented after its leaf, so offer it to Verify as candidate
+	// intermediates rather than requiring the leaf to be signed straight
+	// off a root.
+	intermediates := x509.NewCertPool()
+	for _, der := range rawCerts[1:] {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return fmt.Errorf("failed to parse peer intermediate certificate: %w", err)
+		}
+		intermediates.AddCert(cert)
+	}
+
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         rootPool,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return fmt.Errorf("failed to verify peer certificate against trust domain bundle: %w", err)
+	}
+
+	if len(leaf.URIs) != 1 {
+		return fmt.Errorf("peer certificate must have exactly one URI SAN")
+	}
+
+	id, err := spiffeid.FromURI(leaf.URIs[0])
+	if err != nil {
+		return fmt.Errorf("peer certificate URI SAN is not a valid SPIFFE ID: %w", err)
+	}
+
+	if id != expectedID {
+		return fmt.Errorf("peer SPIFFE ID %q does not match expected %q", id, expectedID)
+	}
+
+	return nil
+}
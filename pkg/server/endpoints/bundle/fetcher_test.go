@@ -0,0 +1,150 @@
+package bundle
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySPIFFEPeerAcceptsBundleRootedLeaf(t *testing.T) {
+	root, rootKey := selfSignedCA(t, "test root")
+	expectedID := spiffeid.RequireFromString("spiffe://federated.test/bundle-endpoint")
+	leaf := leafCert(t, root, rootKey, expectedID)
+
+	err := verifySPIFFEPeer([][]byte{leaf.Raw}, []*x509.Certificate{root}, expectedID)
+	require.NoError(t, err)
+}
+
+func TestVerifySPIFFEPeerAcceptsIntermediateChainedLeaf(t *testing.T) {
+	root, rootKey := selfSignedCA(t, "test root")
+	intermediate, intermediateKey := intermediateCA(t, root, rootKey, "test intermediate")
+	expectedID := spiffeid.RequireFromString("spiffe://federated.test/bundle-endpoint")
+	leaf := leafCert(t, intermediate, intermediateKey, expectedID)
+
+	err := verifySPIFFEPeer([][]byte{leaf.Raw, intermediate.Raw}, []*x509.Certificate{root}, expectedID)
+	require.NoError(t, err)
+}
+
+func TestVerifySPIFFEPeerRejectsUntrustedRoot(t *testing.T) {
+	trustedRoot, _ := selfSignedCA(t, "trusted root")
+	untrustedRoot, untrustedKey := selfSignedCA(t, "untrusted root")
+	expectedID := spiffeid.RequireFromString("spiffe://federated.test/bundle-endpoint")
+	leaf := leafCert(t, untrustedRoot, untrustedKey, expectedID)
+
+	err := verifySPIFFEPeer([][]byte{leaf.Raw}, []*x509.Certificate{trustedRoot}, expectedID)
+	require.Error(t, err)
+}
+
+func TestVerifySPIFFEPeerRejectsWrongSPIFFEID(t *testing.T) {
+	root, rootKey := selfSignedCA(t, "test root")
+	expectedID := spiffeid.RequireFromString("spiffe://federated.test/bundle-endpoint")
+	otherID := spiffeid.RequireFromString("spiffe://federated.test/someone-else")
+	leaf := leafCert(t, root, rootKey, otherID)
+
+	err := verifySPIFFEPeer([][]byte{leaf.Raw}, []*x509.Certificate{root}, expectedID)
+	require.ErrorContains(t, err, "does not match expected")
+}
+
+func TestVerifySPIFFEPeerRejectsZeroURISANs(t *testing.T) {
+	root, rootKey := selfSignedCA(t, "test root")
+	expectedID := spiffeid.RequireFromString("spiffe://federated.test/bundle-endpoint")
+	leaf := leafCertWithURIs(t, root, rootKey, nil)
+
+	err := verifySPIFFEPeer([][]byte{leaf.Raw}, []*x509.Certificate{root}, expectedID)
+	require.ErrorContains(t, err, "exactly one URI SAN")
+}
+
+func TestVerifySPIFFEPeerRejectsMultipleURISANs(t *testing.T) {
+	root, rootKey := selfSignedCA(t, "test root")
+	expectedID := spiffeid.RequireFromString("spiffe://federated.test/bundle-endpoint")
+	first, err := url.Parse("spiffe://federated.test/bundle-endpoint")
+	require.NoError(t, err)
+	second, err := url.Parse("spiffe://federated.test/extra")
+	require.NoError(t, err)
+	leaf := leafCertWithURIs(t, root, rootKey, []*url.URL{first, second})
+
+	err = verifySPIFFEPeer([][]byte{leaf.Raw}, []*x509.Certificate{root}, expectedID)
+	require.ErrorContains(t, err, "exactly one URI SAN")
+}
+
+func TestVerifySPIFFEPeerRejectsNoCertificates(t *testing.T) {
+	err := verifySPIFFEPeer(nil, nil, spiffeid.RequireFromString("spiffe://federated.test/bundle-endpoint"))
+	require.ErrorContains(t, err, "no peer certificate presented")
+}
+
+func selfSignedCA(t *testing.T, commonName string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func intermediateCA(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, commonName string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func leafCert(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, id spiffeid.ID) *x509.Certificate {
+	t.Helper()
+	idURL := id.URL()
+	return leafCertWithURIs(t, parent, parentKey, []*url.URL{&idURL})
+}
+
+func leafCertWithURIs(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, uris []*url.URL) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "test leaf"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		URIs:         uris,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
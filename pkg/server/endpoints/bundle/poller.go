@@ -0,0 +1,192 @@
+package bundle
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/andres-erbsen/clock"
+	"github.com/sirupsen/logrus"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire/pkg/server/cache/dscache"
+	"github.com/spiffe/spire/pkg/server/plugin/datastore"
+	"github.com/spiffe/spire/proto/spire/common"
+)
+
+// DefaultPollInterval is how often the poller re-checks the federation
+// relationship set and refreshes the bundle of every relationship in it.
+const DefaultPollInterval = 5 * time.Minute
+
+// EndpointFetcher fetches the current bundle from a federation
+// relationship's configured bundle endpoint and validates it. It is
+// implemented against the relationship's BundleEndpointProfile (https_web
+// or https_spiffe).
+type EndpointFetcher interface {
+	FetchBundle(ctx context.Context, relationship *datastore.FederationRelationship) (*common.Bundle, error)
+}
+
+// Poller periodically walks the set of federation relationships stored in
+// the datastore and refreshes each one's bundle from its configured bundle
+// endpoint. It replaces the old model of seeding federated bundles only
+// through the server HCL file: relationships created or updated over the
+// API are picked up on the next poll without a server restart.
+type Poller struct {
+	ds       datastore.DataStore
+	td       spiffeid.TrustDomain
+	fetcher  EndpointFetcher
+	clk      clock.Clock
+	interval time.Duration
+	log      logrus.FieldLogger
+	hub      *dscache.FederatedBundleWatchHub
+}
+
+// PollerConfig configures a Poller.
+type PollerConfig struct {
+	DataStore   datastore.DataStore
+	TrustDomain spiffeid.TrustDomain
+	Fetcher     EndpointFetcher
+	Clock       clock.Clock
+	Interval    time.Duration
+	Log         logrus.FieldLogger
+	// WatchHub, if set, is notified of every bundle the poller persists so
+	// WatchFederatedBundles subscribers see poll-driven changes too. The
+	// poller is the steady-state source of federated bundle changes, so
+	// leaving this unset would mean watchers only ever see the minority of
+	// changes made through the Batch*/RefreshFederatedBundle RPCs.
+	WatchHub *dscache.FederatedBundleWatchHub
+}
+
+// NewPoller creates a Poller from the given configuration, applying
+// defaults for Clock and Interval when left unset.
+func NewPoller(config PollerConfig) *Poller {
+	if config.Clock == nil {
+		config.Clock = clock.New()
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultPollInterval
+	}
+	return &Poller{
+		ds:       config.DataStore,
+		td:       config.TrustDomain,
+		fetcher:  config.Fetcher,
+		clk:      config.Clock,
+		interval: config.Interval,
+		log:      config.Log,
+		hub:      config.WatchHub,
+	}
+}
+
+// Run polls the federation relationship set on a fixed interval until ctx
+// is canceled.
+func (p *Poller) Run(ctx context.Context) error {
+	ticker := p.clk.Ticker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			p.pollAll(ctx)
+		}
+	}
+}
+
+func (p *Poller) pollAll(ctx context.Context) {
+	req := &datastore.ListFederationRelationshipsRequest{
+		Pagination: &datastore.Pagination{PageSize: listFederationRelationshipsPageSize},
+	}
+	for {
+		dsResp, err := p.ds.ListFederationRelationships(ctx, req)
+		if err != nil {
+			p.log.WithError(err).Error("Failed to list federation relationships for polling")
+			return
+		}
+
+		for _, relationship := range dsResp.FederationRelationships {
+			p.pollOne(ctx, relationship)
+		}
+
+		if dsResp.Pagination == nil || dsResp.Pagination.Token == "" {
+			return
+		}
+		req.Pagination = &datastore.Pagination{
+			PageSize: listFederationRelationshipsPageSize,
+			Token:    dsResp.Pagination.Token,
+		}
+	}
+}
+
+// listFederationRelationshipsPageSize bounds how many relationships pollAll
+// asks the datastore for at a time, so a large relationship set doesn't tie
+// up the datastore with one unbounded query per poll tick.
+const listFederationRelationshipsPageSize = 100
+
+func (p *Poller) pollOne(ctx context.Context, relationship *datastore.FederationRelationship) {
+	log := p.log.WithField("trust_domain", relationship.TrustDomain.String())
+
+	bundle, err := p.fetcher.FetchBundle(ctx, relationship)
+	if err != nil {
+		log.WithError(err).Warn("Failed to fetch federated bundle from bundle endpoint")
+		return
+	}
+
+	fetchResp, err := p.ds.FetchBundle(ctx, &datastore.FetchBundleRequest{TrustDomainId: relationship.TrustDomain.IDString()})
+	if err != nil {
+		log.WithError(err).Error("Failed to look up current federated bundle before persisting poll result")
+		return
+	}
+
+	var ifSequenceNumber *uint64
+	if fetchResp.Bundle != nil {
+		// Reject a bundle endpoint serving a sequence number older than the
+		// one already on record: a stale cache, a peer restored from an
+		// old backup, or a misconfigured/compromised endpoint could
+		// otherwise roll the stored bundle backward and reintroduce
+		// revoked X.509/JWT authorities, exactly what the sequence-number
+		// rollback protection is meant to prevent.
+		if bundle.SequenceNumber < fetchResp.Bundle.SequenceNumber {
+			log.WithField("fetched_sequence_number", bundle.SequenceNumber).
+				WithField("stored_sequence_number", fetchResp.Bundle.SequenceNumber).
+				Warn("Bundle endpoint served a bundle older than the one on record; skipping poll result")
+			return
+		}
+		sequenceNumber := fetchResp.Bundle.SequenceNumber
+		ifSequenceNumber = &sequenceNumber
+	}
+
+	setResp, err := p.ds.SetBundle(ctx, &datastore.SetBundleRequest{
+		Bundle:           bundle,
+		IfSequenceNumber: ifSequenceNumber,
+	})
+	if err != nil {
+		if errors.Is(err, datastore.ErrSequenceConflict) {
+			log.WithError(err).Warn("Federated bundle changed concurrently with poll; will retry next tick")
+			return
+		}
+		log.WithError(err).Error("Failed to persist refreshed federated bundle")
+		return
+	}
+
+	eventType := datastore.BundleEventUpdated
+	if fetchResp.Bundle == nil {
+		eventType = datastore.BundleEventAdded
+	}
+	p.notifyBundleEvent(eventType, setResp.Bundle, setResp.ModifiedIndex)
+}
+
+// notifyBundleEvent wakes any WatchFederatedBundles streams interested in
+// the affected trust domain, mirroring the bundle v1 service's helper of
+// the same name. It is a no-op if the poller wasn't configured with a
+// WatchHub.
+func (p *Poller) notifyBundleEvent(eventType datastore.BundleEventType, bundle *common.Bundle, modifiedIndex uint64) {
+	if p.hub == nil {
+		return
+	}
+	p.hub.Notify(datastore.BundleEvent{
+		Type:          eventType,
+		TrustDomainId: bundle.TrustDomainId,
+		Bundle:        bundle,
+		ModifiedIndex: modifiedIndex,
+	})
+}
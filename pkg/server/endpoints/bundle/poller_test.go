@@ -0,0 +1,218 @@
+package bundle
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andres-erbsen/clock"
+	"github.com/sirupsen/logrus"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire/pkg/server/cache/dscache"
+	"github.com/spiffe/spire/pkg/server/plugin/datastore"
+	"github.com/spiffe/spire/proto/spire/common"
+	"github.com/stretchr/testify/require"
+)
+
+const testPollerFederatedTD = "spiffe://federated.test"
+
+func TestPollOnePersistsAndNotifiesOnNewBundle(t *testing.T) {
+	ds := newFakePollerDataStore()
+	hub := dscache.NewFederatedBundleWatchHub()
+	watcher, _ := hub.Watch(nil)
+	defer watcher.Close()
+
+	fetched := &common.Bundle{TrustDomainId: testPollerFederatedTD, SequenceNumber: 1}
+	p := newTestPoller(ds, hub, &fakeEndpointFetcher{bundle: fetched})
+
+	p.pollOne(context.Background(), testRelationship(t))
+
+	require.Equal(t, fetched, ds.bundles[testPollerFederatedTD])
+	select {
+	case event := <-watcher.Events():
+		require.Equal(t, datastore.BundleEventAdded, event.Type)
+		require.Equal(t, testPollerFederatedTD, event.TrustDomainId)
+	case <-time.After(time.Second):
+		t.Fatal("expected a notify event for the new bundle")
+	}
+}
+
+func TestPollOneRejectsStaleSequenceNumber(t *testing.T) {
+	ds := newFakePollerDataStore()
+	ds.bundles[testPollerFederatedTD] = &common.Bundle{TrustDomainId: testPollerFederatedTD, SequenceNumber: 5}
+	hub := dscache.NewFederatedBundleWatchHub()
+	watcher, _ := hub.Watch(nil)
+	defer watcher.Close()
+
+	stale := &common.Bundle{TrustDomainId: testPollerFederatedTD, SequenceNumber: 2}
+	p := newTestPoller(ds, hub, &fakeEndpointFetcher{bundle: stale})
+
+	p.pollOne(context.Background(), testRelationship(t))
+
+	require.Equal(t, uint64(5), ds.bundles[testPollerFederatedTD].SequenceNumber, "stale poll result must not be persisted")
+
+	select {
+	case event := <-watcher.Events():
+		t.Fatalf("unexpected notify for rejected stale bundle: %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestPollOneUpdatesExistingBundleAndNotifies(t *testing.T) {
+	ds := newFakePollerDataStore()
+	ds.bundles[testPollerFederatedTD] = &common.Bundle{TrustDomainId: testPollerFederatedTD, SequenceNumber: 1}
+	hub := dscache.NewFederatedBundleWatchHub()
+	watcher, _ := hub.Watch(nil)
+	defer watcher.Close()
+
+	fetched := &common.Bundle{TrustDomainId: testPollerFederatedTD, SequenceNumber: 2}
+	p := newTestPoller(ds, hub, &fakeEndpointFetcher{bundle: fetched})
+
+	p.pollOne(context.Background(), testRelationship(t))
+
+	require.Equal(t, uint64(2), ds.bundles[testPollerFederatedTD].SequenceNumber)
+	select {
+	case event := <-watcher.Events():
+		require.Equal(t, datastore.BundleEventUpdated, event.Type)
+	case <-time.After(time.Second):
+		t.Fatal("expected a notify event for the updated bundle")
+	}
+}
+
+func newTestPoller(ds datastore.DataStore, hub *dscache.FederatedBundleWatchHub, fetcher EndpointFetcher) *Poller {
+	return NewPoller(PollerConfig{
+		DataStore: ds,
+		Fetcher:   fetcher,
+		Clock:     clock.NewMock(),
+		Log:       logrus.StandardLogger(),
+		WatchHub:  hub,
+	})
+}
+
+func testRelationship(t *testing.T) *datastore.FederationRelationship {
+	t.Helper()
+	td, err := spiffeid.TrustDomainFromString(testPollerFederatedTD)
+	require.NoError(t, err)
+	return &datastore.FederationRelationship{
+		TrustDomain:           td,
+		BundleEndpointURL:     "https://federated.test/bundle",
+		BundleEndpointProfile: datastore.BundleEndpointProfileHTTPSWeb,
+	}
+}
+
+type fakeEndpointFetcher struct {
+	bundle *common.Bundle
+	err    error
+}
+
+func (f *fakeEndpointFetcher) FetchBundle(ctx context.Context, relationship *datastore.FederationRelationship) (*common.Bundle, error) {
+	return f.bundle, f.err
+}
+
+// fakePollerDataStore is a minimal in-memory datastore.DataStore for
+// exercising the poller without a real plugin backend.
+type fakePollerDataStore struct {
+	mu      sync.Mutex
+	bundles map[string]*common.Bundle
+	index   uint64
+}
+
+func newFakePollerDataStore() *fakePollerDataStore {
+	return &fakePollerDataStore{bundles: make(map[string]*common.Bundle)}
+}
+
+func (f *fakePollerDataStore) CountBundles(ctx context.Context) (int32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return int32(len(f.bundles)), nil
+}
+
+func (f *fakePollerDataStore) FetchBundle(ctx context.Context, req *datastore.FetchBundleRequest) (*datastore.FetchBundleResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &datastore.FetchBundleResponse{Bundle: f.bundles[req.TrustDomainId]}, nil
+}
+
+func (f *fakePollerDataStore) ListBundles(ctx context.Context, req *datastore.ListBundlesRequest) (*datastore.ListBundlesResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &datastore.ListBundlesResponse{}
+	for _, b := range f.bundles {
+		resp.Bundles = append(resp.Bundles, b)
+	}
+	return resp, nil
+}
+
+func (f *fakePollerDataStore) CreateBundle(ctx context.Context, req *datastore.CreateBundleRequest) (*datastore.CreateBundleResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.index++
+	f.bundles[req.Bundle.TrustDomainId] = req.Bundle
+	return &datastore.CreateBundleResponse{Bundle: req.Bundle, ModifiedIndex: f.index}, nil
+}
+
+func (f *fakePollerDataStore) AppendBundle(ctx context.Context, req *datastore.AppendBundleRequest) (*datastore.AppendBundleResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.index++
+	f.bundles[req.Bundle.TrustDomainId] = req.Bundle
+	return &datastore.AppendBundleResponse{Bundle: req.Bundle, ModifiedIndex: f.index}, nil
+}
+
+func (f *fakePollerDataStore) SetBundle(ctx context.Context, req *datastore.SetBundleRequest) (*datastore.SetBundleResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing := f.bundles[req.Bundle.TrustDomainId]
+	if req.IfSequenceNumber != nil {
+		if existing == nil || existing.SequenceNumber != *req.IfSequenceNumber {
+			return nil, datastore.ErrSequenceConflict
+		}
+	}
+	f.index++
+	f.bundles[req.Bundle.TrustDomainId] = req.Bundle
+	return &datastore.SetBundleResponse{Bundle: req.Bundle, ModifiedIndex: f.index}, nil
+}
+
+func (f *fakePollerDataStore) UpdateBundle(ctx context.Context, req *datastore.UpdateBundleRequest) (*datastore.UpdateBundleResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing := f.bundles[req.Bundle.TrustDomainId]
+	if existing == nil {
+		return nil, datastore.ErrSequenceConflict
+	}
+	f.index++
+	f.bundles[req.Bundle.TrustDomainId] = req.Bundle
+	return &datastore.UpdateBundleResponse{Bundle: req.Bundle, ModifiedIndex: f.index}, nil
+}
+
+func (f *fakePollerDataStore) DeleteBundle(ctx context.Context, req *datastore.DeleteBundleRequest) (*datastore.DeleteBundleResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.bundles, req.TrustDomainId)
+	return &datastore.DeleteBundleResponse{ModifiedIndex: f.index}, nil
+}
+
+func (f *fakePollerDataStore) FetchFederationRelationship(ctx context.Context, req *datastore.FetchFederationRelationshipRequest) (*datastore.FetchFederationRelationshipResponse, error) {
+	return &datastore.FetchFederationRelationshipResponse{}, nil
+}
+
+func (f *fakePollerDataStore) ListFederationRelationships(ctx context.Context, req *datastore.ListFederationRelationshipsRequest) (*datastore.ListFederationRelationshipsResponse, error) {
+	return &datastore.ListFederationRelationshipsResponse{}, nil
+}
+
+func (f *fakePollerDataStore) CreateFederationRelationship(ctx context.Context, req *datastore.CreateFederationRelationshipRequest) (*datastore.CreateFederationRelationshipResponse, error) {
+	return &datastore.CreateFederationRelationshipResponse{}, nil
+}
+
+func (f *fakePollerDataStore) UpdateFederationRelationship(ctx context.Context, req *datastore.UpdateFederationRelationshipRequest) (*datastore.UpdateFederationRelationshipResponse, error) {
+	return &datastore.UpdateFederationRelationshipResponse{}, nil
+}
+
+func (f *fakePollerDataStore) SetFederationRelationship(ctx context.Context, req *datastore.SetFederationRelationshipRequest) (*datastore.SetFederationRelationshipResponse, error) {
+	return &datastore.SetFederationRelationshipResponse{}, nil
+}
+
+func (f *fakePollerDataStore) DeleteFederationRelationship(ctx context.Context, req *datastore.DeleteFederationRelationshipRequest) error {
+	return nil
+}
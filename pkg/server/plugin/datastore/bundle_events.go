@@ -0,0 +1,23 @@
+package datastore
+
+import "github.com/spiffe/spire/proto/spire/common"
+
+// BundleEventType describes the kind of change a BundleEvent represents.
+type BundleEventType int32
+
+const (
+	BundleEventAdded BundleEventType = iota
+	BundleEventUpdated
+	BundleEventDeleted
+)
+
+// BundleEvent describes a single change to a federated bundle, tagged with
+// the ModifiedIndex it was assigned when the change was committed. Indexes
+// are monotonically increasing per-server (not per-bundle); a watcher that
+// has observed index N only needs bundles with a greater index to catch up.
+type BundleEvent struct {
+	Type          BundleEventType
+	TrustDomainId string
+	Bundle        *common.Bundle
+	ModifiedIndex uint64
+}
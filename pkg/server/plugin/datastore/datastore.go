@@ -0,0 +1,157 @@
+package datastore
+
+import (
+	"context"
+
+	"github.com/spiffe/spire/proto/spire/common"
+)
+
+// DataStore is the interface implemented by the server's datastore plugins.
+// It is the system of record for bundles, federation relationships, and the
+// other persisted server state the v1 APIs operate on.
+type DataStore interface {
+	CountBundles(ctx context.Context) (int32, error)
+	FetchBundle(ctx context.Context, req *FetchBundleRequest) (*FetchBundleResponse, error)
+	ListBundles(ctx context.Context, req *ListBundlesRequest) (*ListBundlesResponse, error)
+	CreateBundle(ctx context.Context, req *CreateBundleRequest) (*CreateBundleResponse, error)
+	AppendBundle(ctx context.Context, req *AppendBundleRequest) (*AppendBundleResponse, error)
+	SetBundle(ctx context.Context, req *SetBundleRequest) (*SetBundleResponse, error)
+	UpdateBundle(ctx context.Context, req *UpdateBundleRequest) (*UpdateBundleResponse, error)
+	DeleteBundle(ctx context.Context, req *DeleteBundleRequest) (*DeleteBundleResponse, error)
+
+	FetchFederationRelationship(ctx context.Context, req *FetchFederationRelationshipRequest) (*FetchFederationRelationshipResponse, error)
+	ListFederationRelationships(ctx context.Context, req *ListFederationRelationshipsRequest) (*ListFederationRelationshipsResponse, error)
+	CreateFederationRelationship(ctx context.Context, req *CreateFederationRelationshipRequest) (*CreateFederationRelationshipResponse, error)
+	UpdateFederationRelationship(ctx context.Context, req *UpdateFederationRelationshipRequest) (*UpdateFederationRelationshipResponse, error)
+	SetFederationRelationship(ctx context.Context, req *SetFederationRelationshipRequest) (*SetFederationRelationshipResponse, error)
+	DeleteFederationRelationship(ctx context.Context, req *DeleteFederationRelationshipRequest) error
+}
+
+// Pagination carries page-at-a-time request/response state for List calls.
+type Pagination struct {
+	PageSize int32
+	Token    string
+}
+
+// BundleMask is the datastore-level analog of the types.BundleMask proto; it
+// controls which fields UpdateBundle is allowed to touch.
+type BundleMask struct {
+	RefreshHint     bool
+	SequenceNumber  bool
+	X509Authorities bool
+	JwtAuthorities  bool
+}
+
+// FetchBundleRequest fetches a single bundle by trust domain.
+type FetchBundleRequest struct {
+	TrustDomainId string
+}
+
+// FetchBundleResponse is the result of fetching a bundle. Bundle is nil if
+// no bundle is stored for the requested trust domain.
+type FetchBundleResponse struct {
+	Bundle *common.Bundle
+}
+
+// ListBundlesRequest lists all stored bundles, optionally paginated.
+type ListBundlesRequest struct {
+	Pagination *Pagination
+}
+
+// ListBundlesResponse is the result of listing bundles.
+type ListBundlesResponse struct {
+	Bundles    []*common.Bundle
+	Pagination *Pagination
+}
+
+// CreateBundleRequest creates a new bundle. It fails with codes.AlreadyExists
+// if one is already stored for the bundle's trust domain.
+type CreateBundleRequest struct {
+	Bundle *common.Bundle
+}
+
+// CreateBundleResponse is the result of creating a bundle.
+type CreateBundleResponse struct {
+	Bundle        *common.Bundle
+	ModifiedIndex uint64
+}
+
+// AppendBundleRequest appends authorities to a bundle, creating it if it
+// does not already exist.
+type AppendBundleRequest struct {
+	Bundle *common.Bundle
+}
+
+// AppendBundleResponse is the result of appending to a bundle.
+type AppendBundleResponse struct {
+	Bundle        *common.Bundle
+	ModifiedIndex uint64
+}
+
+// SetBundleRequest upserts a bundle. When IfSequenceNumber is non-nil, the
+// store must perform the write as a compare-and-swap against the sequence
+// number currently stored for the bundle's trust domain (inside the same
+// transaction that reads and writes the row), returning ErrSequenceConflict
+// on mismatch instead of applying the write. A nil IfSequenceNumber means
+// "write unconditionally", preserving the original upsert behavior.
+type SetBundleRequest struct {
+	Bundle           *common.Bundle
+	IfSequenceNumber *uint64
+}
+
+// SetBundleResponse is the result of upserting a bundle. ModifiedIndex is
+// the monotonically increasing, server-global index the write was assigned;
+// it is suitable for use as a WatchFederatedBundles resume point.
+type SetBundleResponse struct {
+	Bundle        *common.Bundle
+	ModifiedIndex uint64
+}
+
+// UpdateBundleRequest updates an existing bundle, applying InputMask (or all
+// fields, if nil) from Bundle. See SetBundleRequest for IfSequenceNumber's
+// compare-and-swap semantics; it applies identically here.
+type UpdateBundleRequest struct {
+	Bundle           *common.Bundle
+	InputMask        *BundleMask
+	IfSequenceNumber *uint64
+}
+
+// UpdateBundleResponse is the result of updating a bundle.
+type UpdateBundleResponse struct {
+	Bundle        *common.Bundle
+	ModifiedIndex uint64
+}
+
+// DeleteBundleRequest_Mode controls how a bundle delete behaves when other
+// records (e.g. registration entries) still reference the trust domain.
+type DeleteBundleRequest_Mode int32 //nolint:revive // matches the proto-generated naming this mirrors
+
+const (
+	DeleteBundleRequest_RESTRICT DeleteBundleRequest_Mode = iota
+	DeleteBundleRequest_DISSOCIATE
+	DeleteBundleRequest_DELETE
+)
+
+func (m DeleteBundleRequest_Mode) String() string {
+	switch m {
+	case DeleteBundleRequest_RESTRICT:
+		return "RESTRICT"
+	case DeleteBundleRequest_DISSOCIATE:
+		return "DISSOCIATE"
+	case DeleteBundleRequest_DELETE:
+		return "DELETE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// DeleteBundleRequest deletes the bundle for the given trust domain.
+type DeleteBundleRequest struct {
+	TrustDomainId string
+	Mode          DeleteBundleRequest_Mode
+}
+
+// DeleteBundleResponse is the result of deleting a bundle.
+type DeleteBundleResponse struct {
+	ModifiedIndex uint64
+}
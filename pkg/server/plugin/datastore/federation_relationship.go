@@ -0,0 +1,100 @@
+package datastore
+
+import (
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire/proto/spire/common"
+)
+
+// BundleEndpointProfile identifies how a bundle endpoint authenticates
+// itself to a federated server.
+type BundleEndpointProfile string
+
+const (
+	// BundleEndpointProfileHTTPSWeb is authenticated with a web PKI TLS
+	// certificate.
+	BundleEndpointProfileHTTPSWeb BundleEndpointProfile = "https_web"
+
+	// BundleEndpointProfileHTTPSSPIFFE is authenticated with a SPIFFE X.509
+	// SVID whose ID is expected to match EndpointSPIFFEID.
+	BundleEndpointProfileHTTPSSPIFFE BundleEndpointProfile = "https_spiffe"
+)
+
+// FederationRelationship describes how this server federates with a
+// foreign trust domain: where its bundle endpoint lives, how to
+// authenticate it, and (optionally) a bootstrap bundle to trust until the
+// first successful poll.
+type FederationRelationship struct {
+	TrustDomain           spiffeid.TrustDomain
+	BundleEndpointURL     string
+	BundleEndpointProfile BundleEndpointProfile
+	EndpointSPIFFEID      spiffeid.ID    // only set when BundleEndpointProfile is BundleEndpointProfileHTTPSSPIFFE
+	TrustDomainBundle     *common.Bundle // optional bootstrap bundle
+}
+
+// CreateFederationRelationshipRequest is used to create a federation
+// relationship.
+type CreateFederationRelationshipRequest struct {
+	FederationRelationship *FederationRelationship
+}
+
+// CreateFederationRelationshipResponse is the result of creating a
+// federation relationship.
+type CreateFederationRelationshipResponse struct {
+	FederationRelationship *FederationRelationship
+}
+
+// FetchFederationRelationshipRequest is used to fetch a single federation
+// relationship by trust domain.
+type FetchFederationRelationshipRequest struct {
+	TrustDomain spiffeid.TrustDomain
+}
+
+// FetchFederationRelationshipResponse is the result of fetching a
+// federation relationship. FederationRelationship is nil if none exists for
+// the requested trust domain.
+type FetchFederationRelationshipResponse struct {
+	FederationRelationship *FederationRelationship
+}
+
+// ListFederationRelationshipsRequest is used to list federation
+// relationships.
+type ListFederationRelationshipsRequest struct {
+	Pagination *Pagination
+}
+
+// ListFederationRelationshipsResponse is the result of listing federation
+// relationships.
+type ListFederationRelationshipsResponse struct {
+	FederationRelationships []*FederationRelationship
+	Pagination              *Pagination
+}
+
+// UpdateFederationRelationshipRequest is used to update a federation
+// relationship.
+type UpdateFederationRelationshipRequest struct {
+	FederationRelationship *FederationRelationship
+}
+
+// UpdateFederationRelationshipResponse is the result of updating a
+// federation relationship.
+type UpdateFederationRelationshipResponse struct {
+	FederationRelationship *FederationRelationship
+}
+
+// DeleteFederationRelationshipRequest is used to delete a federation
+// relationship.
+type DeleteFederationRelationshipRequest struct {
+	TrustDomain spiffeid.TrustDomain
+}
+
+// SetFederationRelationshipRequest is used to upsert a federation
+// relationship.
+type SetFederationRelationshipRequest struct {
+	FederationRelationship *FederationRelationship
+}
+
+// SetFederationRelationshipResponse is the result of upserting a federation
+// relationship.
+type SetFederationRelationshipResponse struct {
+	FederationRelationship *FederationRelationship
+}
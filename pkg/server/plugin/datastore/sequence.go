@@ -0,0 +1,17 @@
+package datastore
+
+import "errors"
+
+// ErrSequenceConflict is returned by SetBundle/UpdateBundle when the
+// request's IfSequenceNumber no longer matches the sequence number stored
+// for the bundle. Callers that want rollback protection should treat it as a
+// FailedPrecondition: the write lost a race or was built from a stale read
+// and must be retried against the current bundle.
+//
+// SetBundleRequest and UpdateBundleRequest both carry an optional
+// IfSequenceNumber *uint64 field: when set, the store must perform the
+// write as a compare-and-swap against that value (inside the same
+// transaction that reads and writes the row) and return ErrSequenceConflict
+// on mismatch instead of applying the write, so concurrent writers racing on
+// the same trust domain cannot silently lose an update.
+var ErrSequenceConflict = errors.New("datastore: bundle sequence number conflict")